@@ -1,17 +1,31 @@
 package main
 
 import (
+	"bytes"
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	"io/ioutil"
-	"log"
+	"log/slog"
 	"net/http"
 	"net/url"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"sync"
+	"syscall"
 	"time"
 
 	"goji.io"
+	"goji.io/middleware"
 	"goji.io/pat"
 
 	"firefight"
@@ -19,21 +33,489 @@ import (
 
 var ffServers sync.Map
 
-func loadServer(id string) *firefight.FireFight {
-	ffi, ok := ffServers.Load(id)
-	if ok {
+var store firefight.Store
+
+var commandsTotal = firefight.NewCounter("firefight_commands_total",
+	"Slash commands and button clicks received, by command.")
+
+var requestSeconds = firefight.NewHistogram("firefight_request_duration_seconds",
+	"Time taken to handle slash commands and button clicks, by command.", firefight.DefaultBuckets)
+
+// loadMu serializes loading games that aren't in memory, so each is only
+// loaded, created or adopted once.
+var loadMu sync.Mutex
+
+func loadServer(key firefight.GameKey) *firefight.FireFight {
+	id := key.String()
+	if ffi, ok := ffServers.Load(id); ok {
+		return ffi.(*firefight.FireFight)
+	}
+
+	loadMu.Lock()
+	defer loadMu.Unlock()
+
+	if ffi, ok := ffServers.Load(id); ok {
 		return ffi.(*firefight.FireFight)
 	}
 
-	ffi, loaded := ffServers.LoadOrStore(id, firefight.New())
-	if !loaded {
-		log.Printf("[ffserver][%s] Created.\n", id)
+	ff, err := store.Load(id)
+	if err != nil {
+		slog.Error("load failed", "game", id, "error", err)
+	}
+
+	// Games used to be keyed by channel alone. The first workspace to use
+	// the channel takes its game over.
+	adopted := false
+	if ff == nil && err == nil && key.TeamID != "" {
+		ff, err = store.Load(key.ChannelID)
+		if err != nil {
+			slog.Error("load failed", "game", key.ChannelID, "error", err)
+		}
+		adopted = ff != nil
+	}
+
+	if adopted {
+		adoptServer(key, ff)
+	}
+
+	ff = catchUp(id, ff)
+
+	created := ff == nil
+	if created {
+		ff = firefight.New()
+	}
+	setupServer(key, ff)
+	ffServers.Store(id, ff)
+
+	switch {
+	case created:
+		slog.Info("game created", "game", id)
+	case adopted:
+		slog.Info("game adopted", "game", id, "from", key.ChannelID)
+	default:
+		slog.Info("game restored", "game", id)
+	}
+
+	return ff
+}
+
+// adoptServer moves channel-only game 'ff' and its event log over to 'key'.
+// The old snapshot goes once the game is saved under its new key, so it
+// can't be adopted twice.
+//
+// loadMu must be held.
+func adoptServer(key firefight.GameKey, ff *firefight.FireFight) {
+	id := key.String()
+
+	err := os.Rename(eventLogPath(key.ChannelID), eventLogPath(id))
+	if err != nil && !os.IsNotExist(err) {
+		slog.Error("move event log failed", "game", id, "from", key.ChannelID, "error", err)
+		return
+	}
+
+	if err := store.Save(id, ff); err != nil {
+		slog.Error("save failed", "game", id, "error", err)
+		return
+	}
+
+	if err := store.Delete(key.ChannelID); err != nil {
+		slog.Error("delete failed", "game", key.ChannelID, "error", err)
+	}
+}
+
+// catchUp brings snapshot 'ff' of game 'id' up to date with its event log,
+// which is written first and so survives a crash the snapshot didn't.
+// Without a snapshot the game is rebuilt from the log alone. Returns nil if
+// there's neither.
+func catchUp(id string, ff *firefight.FireFight) *firefight.FireFight {
+	l := eventLog(id)
+
+	if ff == nil {
+		events, err := l.Events()
+		if err != nil {
+			slog.Error("reading event log failed", "game", id, "error", err)
+		}
+
+		if len(events) == 0 {
+			return nil
+		}
+
+		ff = firefight.Replay(events)
+		slog.Warn("game rebuilt from its event log", "game", id, "events", len(events))
+		saveServer(id, ff)
+		return ff
+	}
+
+	n, err := ff.CatchUp(l)
+	if err != nil {
+		slog.Error("reading event log failed", "game", id, "error", err)
+	}
+
+	if n > 0 {
+		slog.Warn("game caught up with its event log", "game", id, "events", n)
+		saveServer(id, ff)
+	}
+
+	return ff
+}
+
+// setupServer wires a loaded game to its logger, event log, store and
+// workspace.
+func setupServer(key firefight.GameKey, ff *firefight.FireFight) {
+	wc := firefight.Workspaces.For(key.TeamID)
+
+	ff.SetLogger(slog.With("game", key.String(), "channel", key.ChannelID))
+	ff.SetEventLog(eventLog(key.String()))
+	ff.SetStore(store, key.String())
+	ff.SetNotifier(&firefight.Notifier{WebhookURL: wc.Webhooks[key.ChannelID]})
+
+	// Checked by loadWorkspaces.
+	if cfg, err := wc.GameConfig(); err == nil {
+		ff.SetDefaults(cfg)
+	}
+}
+
+func saveServer(id string, ff *firefight.FireFight) {
+	if err := store.Save(id, ff); err != nil {
+		slog.Error("save failed", "game", id, "error", err)
+	}
+}
+
+// gameLocks holds a lock per game ID. Requests hold it for reading from
+// loading the game until it's saved, the sweeper for writing to evict it.
+// Locks outlive the games they guard, they're tiny.
+var gameLocks sync.Map
+
+func gameLock(id string) *sync.RWMutex {
+	l, _ := gameLocks.LoadOrStore(id, new(sync.RWMutex))
+	return l.(*sync.RWMutex)
+}
+
+// restoreServers loads every saved game before serving requests.
+// Dormant games are left on disk until used.
+func restoreServers(ttl time.Duration) error {
+	games, err := store.LoadAll()
+	if err != nil {
+		return err
+	}
+
+	since := time.Now().Add(-ttl)
+	for id, ff := range games {
+		key := firefight.ParseGameKey(id)
+		if key.TeamID == "" {
+			continue // Old channel-only game, adopted on first use.
+		}
+
+		ff = catchUp(id, ff)
+		if ff.Dormant(since) {
+			continue
+		}
+
+		setupServer(key, ff)
+		ffServers.Store(id, ff)
+		slog.Info("game restored", "game", id)
+	}
+
+	return nil
+}
+
+// DefaultIdleTTL is how long empty lobbies and finished games stay in
+// memory without a change.
+const DefaultIdleTTL = 24 * time.Hour
+
+// sweepInterval is how often the sweeper looks for dormant games.
+const sweepInterval = 10 * time.Minute
+
+func idleTTL() time.Duration {
+	return envDuration("FIREFIGHT_IDLE_TTL", DefaultIdleTTL)
+}
+
+// sweep evicts games that have been dormant for 'ttl'. Finished games are
+// archived first. Everything is saved so the game can be loaded again.
+func sweep(ttl time.Duration) {
+	since := time.Now().Add(-ttl)
+
+	ffServers.Range(func(key, value interface{}) bool {
+		if value.(*firefight.FireFight).Dormant(since) {
+			evict(key.(string), since)
+		}
+		return true
+	})
+}
+
+// evict drops game 'id' from memory once no request is using it, unless
+// one changed it in the meantime.
+func evict(id string, since time.Time) {
+	lock := gameLock(id)
+	lock.Lock()
+	defer lock.Unlock()
+
+	ffi, ok := ffServers.Load(id)
+	if !ok {
+		return
+	}
+
+	ff := ffi.(*firefight.FireFight)
+	if !ff.Dormant(since) {
+		return
+	}
+
+	if ff.CurrentState() == firefight.StateFinished {
+		if err := store.Archive(id, ff); err != nil {
+			slog.Error("archive failed", "game", id, "error", err)
+			return
+		}
+	}
+
+	if err := store.Save(id, ff); err != nil {
+		slog.Error("save failed", "game", id, "error", err)
+		return
+	}
+
+	ffServers.Delete(id)
+	slog.Info("game evicted", "game", id)
+}
+
+// startSweeper runs sweep in the background for as long as the server runs.
+func startSweeper(ttl time.Duration) {
+	go func() {
+		for range time.Tick(sweepInterval) {
+			sweep(ttl)
+		}
+	}()
+}
+
+func eventLog(id string) firefight.EventLog {
+	return firefight.NewFileEventLog(eventLogPath(id))
+}
+
+func eventLogPath(id string) string {
+	return filepath.Join(dataDir(), "events", filepath.Base(id)+".jsonl")
+}
+
+// loadWorkspaces reads per-workspace settings from the JSON file named by
+// FIREFIGHT_WORKSPACES, e.g.
+//
+//	{"T0123": {"BotToken": "xoxb-...", "Webhooks": {"C0123": "https://hooks.slack.com/..."}, "Rules": "shuffle=off"}}
+//
+// Team "*" covers every other workspace. Unless the file sets it, it's
+// built from FIREFIGHT_BOT_TOKEN and FIREFIGHT_WEBHOOKS, the latter as
+// "C0123=https://hooks.slack.com/...,C0456=...".
+func loadWorkspaces() (firefight.WorkspaceConfigs, error) {
+	wcs := make(firefight.WorkspaceConfigs)
+	if path := os.Getenv("FIREFIGHT_WORKSPACES"); path != "" {
+		var err error
+		if wcs, err = firefight.LoadWorkspaceConfigs(path); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, ok := wcs[firefight.DefaultTeam]; !ok {
+		wcs[firefight.DefaultTeam] = &firefight.WorkspaceConfig{
+			BotToken: os.Getenv("FIREFIGHT_BOT_TOKEN"),
+			Webhooks: parseWebhooks(os.Getenv("FIREFIGHT_WEBHOOKS")),
+		}
+	}
+
+	for team, wc := range wcs {
+		if wc.BotToken == "" {
+			slog.Warn("no bot token, workspace admins can't override game permissions", "team", team)
+		}
+	}
+
+	return wcs, nil
+}
+
+func parseWebhooks(v string) map[string]string {
+	hooks := make(map[string]string)
+	for _, pair := range strings.Split(v, ",") {
+		kv := strings.SplitN(strings.TrimSpace(pair), "=", 2)
+		if len(kv) == 2 {
+			hooks[kv[0]] = kv[1]
+		}
+	}
+
+	return hooks
+}
+
+func dataDir() string {
+	if dir := os.Getenv("FIREFIGHT_DATA_DIR"); dir != "" {
+		return dir
+	}
+
+	return "data"
+}
+
+// serverConfig is how the server is run. Every flag falls back to an
+// environment variable.
+type serverConfig struct {
+	Addr string
+
+	// Serve HTTPS when both are set.
+	TLSCert string
+	TLSKey  string
+
+	ReadTimeout     time.Duration
+	WriteTimeout    time.Duration
+	ShutdownTimeout time.Duration // How long in-flight requests get to finish.
+
+	SigningSecret      string
+	InsecureSkipVerify bool // Accept unsigned requests, for local testing only.
+
+	LogLevel slog.Level
+}
+
+func envOr(name, def string) string {
+	if v := os.Getenv(name); v != "" {
+		return v
+	}
+
+	return def
+}
+
+func envDuration(name string, def time.Duration) time.Duration {
+	v := os.Getenv(name)
+	if v == "" {
+		return def
+	}
+
+	d, err := time.ParseDuration(v)
+	if err != nil || d <= 0 {
+		slog.Warn("bad duration, using default", "env", name, "value", v, "default", def.String())
+		return def
+	}
+
+	return d
+}
+
+// envLevel parses log level 'name', e.g. "debug" or "warn".
+func envLevel(name string, def slog.Level) slog.Level {
+	v := os.Getenv(name)
+	if v == "" {
+		return def
+	}
+
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(v)); err != nil {
+		slog.Warn("bad log level, using default", "env", name, "value", v, "default", def.String())
+		return def
+	}
+
+	return level
+}
+
+// fatal logs 'msg' and exits.
+func fatal(msg string, args ...interface{}) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
+
+// durationMS is 'd' in milliseconds, for logs.
+func durationMS(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+func parseFlags() serverConfig {
+	var cfg serverConfig
+	flag.StringVar(&cfg.Addr, "addr", envOr("FIREFIGHT_ADDR", ":8081"),
+		"listen address (FIREFIGHT_ADDR)")
+	flag.StringVar(&cfg.TLSCert, "tls-cert", os.Getenv("FIREFIGHT_TLS_CERT"),
+		"TLS certificate file (FIREFIGHT_TLS_CERT)")
+	flag.StringVar(&cfg.TLSKey, "tls-key", os.Getenv("FIREFIGHT_TLS_KEY"),
+		"TLS key file (FIREFIGHT_TLS_KEY)")
+	flag.DurationVar(&cfg.ReadTimeout, "read-timeout", envDuration("FIREFIGHT_READ_TIMEOUT", 10*time.Second),
+		"max time to read a request (FIREFIGHT_READ_TIMEOUT)")
+	flag.DurationVar(&cfg.WriteTimeout, "write-timeout", envDuration("FIREFIGHT_WRITE_TIMEOUT", 10*time.Second),
+		"max time to write a response (FIREFIGHT_WRITE_TIMEOUT)")
+	flag.DurationVar(&cfg.ShutdownTimeout, "shutdown-timeout", envDuration("FIREFIGHT_SHUTDOWN_TIMEOUT", 30*time.Second),
+		"max time to drain requests on SIGTERM (FIREFIGHT_SHUTDOWN_TIMEOUT)")
+	flag.StringVar(&cfg.SigningSecret, "signing-secret", os.Getenv("FIREFIGHT_SIGNING_SECRET"),
+		"Slack signing secret, prefer FIREFIGHT_SIGNING_SECRET to keep it out of ps")
+	flag.BoolVar(&cfg.InsecureSkipVerify, "insecure-skip-verify", false,
+		"accept requests not signed by Slack, for local testing only")
+	flag.TextVar(&cfg.LogLevel, "log-level", envLevel("FIREFIGHT_LOG_LEVEL", slog.LevelInfo),
+		"debug, info, warn or error (FIREFIGHT_LOG_LEVEL)")
+	flag.Parse()
+
+	if (cfg.TLSCert == "") != (cfg.TLSKey == "") {
+		fatal("-tls-cert and -tls-key go together")
+	}
+
+	if cfg.SigningSecret == "" && !cfg.InsecureSkipVerify {
+		fatal("no signing secret, set FIREFIGHT_SIGNING_SECRET or pass -insecure-skip-verify to test locally")
+	}
+
+	return cfg
+}
+
+// serve runs 'srv' until SIGTERM or an interrupt, then waits for in-flight
+// requests to finish.
+func serve(srv *http.Server, cfg serverConfig) {
+	drained := make(chan struct{})
+	go func() {
+		sig := make(chan os.Signal, 1)
+		signal.Notify(sig, syscall.SIGTERM, os.Interrupt)
+		slog.Info("shutting down", "signal", (<-sig).String())
+
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+		defer cancel()
+
+		if err := srv.Shutdown(ctx); err != nil {
+			slog.Error("shutdown failed", "error", err)
+		}
+		close(drained)
+	}()
+
+	slog.Info("listening", "addr", cfg.Addr)
+
+	var err error
+	if cfg.TLSCert != "" {
+		err = srv.ListenAndServeTLS(cfg.TLSCert, cfg.TLSKey)
+	} else {
+		err = srv.ListenAndServe()
+	}
+
+	if err != http.ErrServerClosed {
+		fatal("serve failed", "error", err)
 	}
 
-	return ffi.(*firefight.FireFight)
+	<-drained
+}
+
+// flushServers saves every game in memory.
+func flushServers() {
+	count := 0
+	ffServers.Range(func(key, value interface{}) bool {
+		saveServer(key.(string), value.(*firefight.FireFight))
+		count++
+		return true
+	})
+
+	slog.Info("games saved", "count", count)
 }
 
 func main() {
+	cfg := parseFlags()
+	slog.SetDefault(slog.New(slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{Level: cfg.LogLevel})))
+
+	fstore, err := firefight.NewFileStore(dataDir())
+	if err != nil {
+		fatal("opening store failed", "error", err)
+	}
+	store = fstore
+
+	wcs, err := loadWorkspaces()
+	if err != nil {
+		fatal("loading workspaces failed", "error", err)
+	}
+	firefight.Workspaces = wcs
+
+	ttl := idleTTL()
+	if err := restoreServers(ttl); err != nil {
+		fatal("restoring games failed", "error", err)
+	}
+	startSweeper(ttl)
+
 	// {
 	// 	testIDs := []string{
 	// 		"AAA",
@@ -77,47 +559,118 @@ func main() {
 	// 	ff.Pause()
 	// }
 
+	verify := Verify(cfg.SigningSecret, signatureWindow())
+	if cfg.InsecureSkipVerify {
+		slog.Warn("-insecure-skip-verify given, Slack requests are NOT verified")
+		verify = func(h http.Handler) http.Handler { return h }
+	}
+
 	endpoint := goji.SubMux()
+	endpoint.Use(verify)
 	endpoint.Use(Context)
 	endpoint.Use(func(h http.Handler) http.Handler {
 		fn := func(w http.ResponseWriter, r *http.Request) {
-			scmd, ok := r.Context().Value("slack_cmd").(*firefight.SlackCmd)
+			scmd, ok := firefight.SlackCmdFrom(r.Context())
 			if !ok {
 				http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
 				return
 			}
 
-			defer func(start time.Time) {
-				log.Printf("[ffserver][%s][%s][%s] request completed in: %s",
-					scmd.ChannelID, scmd.Command, scmd.UserID, time.Since(start))
-			}(time.Now())
+			// Routed before middleware runs, so the handler is known.
+			command := "unknown"
+			if c, ok := middleware.Handler(r.Context()).(firefight.NamedCommand); ok {
+				command = c.CommandName(scmd)
+			}
+
+			start := time.Now()
+			key := scmd.Key()
+
+			labels := firefight.Labels("command", command)
+			commandsTotal.Inc(labels)
+
+			lock := gameLock(key.String())
+			lock.RLock()
+			defer lock.RUnlock()
+
+			ff := loadServer(key)
+			ff.Notifier().Track(scmd.ResponseURL)
+
+			logger := firefight.Logger(r.Context()).With(
+				"game", key.String(),
+				"channel", key.ChannelID,
+				"user", scmd.UserID,
+				"command", command,
+				"state", ff.CurrentState().String())
+
+			defer func() {
+				requestSeconds.Observe(labels, time.Since(start).Seconds())
+				logger.Info("request completed",
+					"state_after", ff.CurrentState().String(),
+					"duration_ms", durationMS(time.Since(start)))
+			}()
 
-			ff := loadServer(scmd.ChannelID)
-			ctx := context.WithValue(r.Context(), "fire_fight", ff)
+			ctx := firefight.WithFireFight(r.Context(), ff)
+			ctx = firefight.WithLogger(ctx, logger)
 
 			h.ServeHTTP(w, r.WithContext(ctx))
+
+			saveServer(key.String(), ff)
 		}
 		return http.HandlerFunc(fn)
 	})
 
-	endpoint.HandleFunc(pat.Post("/ffstart"), firefight.Start)
-	endpoint.HandleFunc(pat.Post("/ffpause"), firefight.Pause)
-	endpoint.HandleFunc(pat.Post("/ffend"), firefight.End)
+	endpoint.Handle(pat.Post("/ff"), firefight.Named("", firefight.Command))
+
+	endpoint.Handle(pat.Post("/ffstart"), firefight.Named("start", firefight.Start))
+	endpoint.Handle(pat.Post("/ffpause"), firefight.Named("pause", firefight.Pause))
+	endpoint.Handle(pat.Post("/ffend"), firefight.Named("end", firefight.End))
+	endpoint.Handle(pat.Post("/ffreset"), firefight.Named("reset", firefight.Reset))
+	endpoint.Handle(pat.Post("/ffadmin"), firefight.Named("admin", firefight.Admin))
+	endpoint.Handle(pat.Post("/ffkick"), firefight.Named("kick", firefight.Kick))
+	endpoint.Handle(pat.Post("/ffban"), firefight.Named("ban", firefight.Ban))
+	endpoint.Handle(pat.Post("/ffunban"), firefight.Named("unban", firefight.Unban))
 
-	endpoint.HandleFunc(pat.Post("/ffjoin"), firefight.Join)
-	endpoint.HandleFunc(pat.Post("/fftarget"), firefight.Target)
-	endpoint.HandleFunc(pat.Post("/ffhit"), firefight.ReportHit)
-	endpoint.HandleFunc(pat.Post("/ffdispute"), firefight.DisputeHit)
-	endpoint.HandleFunc(pat.Post("/ffdefended"), firefight.DefendAttack)
+	endpoint.Handle(pat.Post("/ffjoin"), firefight.Named("join", firefight.Join))
+	endpoint.Handle(pat.Post("/ffleave"), firefight.Named("leave", firefight.Leave))
+	endpoint.Handle(pat.Post("/fftarget"), firefight.Named("target", firefight.Target))
+	endpoint.Handle(pat.Post("/ffhit"), firefight.Named("hit", firefight.ReportHit))
+	endpoint.Handle(pat.Post("/ffdispute"), firefight.Named("dispute", firefight.DisputeHit))
+	endpoint.Handle(pat.Post("/ffconfirm"), firefight.Named("confirm", firefight.ConfirmHit))
+	endpoint.Handle(pat.Post("/ffref"), firefight.Named("ref", firefight.Referee))
+	endpoint.Handle(pat.Post("/ffdefended"), firefight.Named("defend", firefight.DefendAttack))
 
-	endpoint.HandleFunc(pat.Post("/ffscore"), firefight.Scoreboard)
+	endpoint.Handle(pat.Post("/ffscore"), firefight.Named("score", firefight.Scoreboard))
 
 	mux := goji.NewMux()
+	mux.Use(RequestID)
 	mux.Handle(pat.New("/endpoint/*"), endpoint)
-	mux.Handle(pat.New("/debug/*"), DebugRoutes())
+	mux.Handle(pat.Post("/interactive"), verify(InteractionContext(http.HandlerFunc(firefight.Interactive))))
+	mux.Handle(pat.New("/debug/*"), DebugRoutes(adminCredentials()))
+	mux.HandleFunc(pat.Get("/metrics"), Metrics)
 	mux.HandleFunc(pat.Get("/"), Index)
 
-	log.Fatal(http.ListenAndServe(":8081", mux))
+	srv := &http.Server{
+		Addr:         cfg.Addr,
+		Handler:      mux,
+		ReadTimeout:  cfg.ReadTimeout,
+		WriteTimeout: cfg.WriteTimeout,
+	}
+
+	serve(srv, cfg)
+	flushServers()
+}
+
+// RequestID tags every request with an ID, sent back as X-Request-Id and
+// logged with every line the request causes.
+func RequestID(h http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		id := firefight.NewRequestID()
+		w.Header().Set("X-Request-Id", id)
+
+		logger := slog.Default().With("request_id", id)
+		h.ServeHTTP(w, r.WithContext(firefight.WithLogger(r.Context(), logger)))
+	}
+	return http.HandlerFunc(fn)
 }
 
 func Context(h http.Handler) http.Handler {
@@ -126,52 +679,316 @@ func Context(h http.Handler) http.Handler {
 
 		body, err := ioutil.ReadAll(r.Body)
 		if err != nil {
-			log.Println("[Context]", err)
+			firefight.Logger(r.Context()).Warn("bad request", "error", err)
 			http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
 			return
 		}
 
 		m, err := url.ParseQuery(string(body))
 		if err != nil {
-			log.Println("[Context]", err)
+			firefight.Logger(r.Context()).Warn("bad request", "error", err)
 			http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
 			return
 		}
 
 		cmd := firefight.ParseSlackCmd(m)
-		ctx := context.WithValue(r.Context(), "slack_cmd", cmd)
+		ctx := firefight.WithSlackCmd(r.Context(), cmd)
+
+		h.ServeHTTP(w, r.WithContext(ctx))
+	}
+	return http.HandlerFunc(fn)
+}
+
+// InteractionContext parses a button click and loads the game of the channel
+// it came from.
+func InteractionContext(h http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		defer r.Body.Close()
+
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			firefight.Logger(r.Context()).Warn("bad request", "error", err)
+			http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+			return
+		}
+
+		m, err := url.ParseQuery(string(body))
+		if err != nil {
+			firefight.Logger(r.Context()).Warn("bad request", "error", err)
+			http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+			return
+		}
+
+		si, err := firefight.ParseSlackInteraction(m)
+		if err != nil {
+			firefight.Logger(r.Context()).Warn("bad request", "error", err)
+			http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+			return
+		}
+
+		start := time.Now()
+		key := si.Key()
+
+		labels := firefight.Labels("command", "interactive")
+		commandsTotal.Inc(labels)
+
+		lock := gameLock(key.String())
+		lock.RLock()
+		defer lock.RUnlock()
+
+		ff := loadServer(key)
+		ff.Notifier().Track(si.ResponseURL)
+
+		logger := firefight.Logger(r.Context()).With(
+			"game", key.String(),
+			"channel", key.ChannelID,
+			"user", si.User.ID,
+			"command", "interactive",
+			"state", ff.CurrentState().String())
+
+		defer func() {
+			requestSeconds.Observe(labels, time.Since(start).Seconds())
+			logger.Info("request completed",
+				"state_after", ff.CurrentState().String(),
+				"duration_ms", durationMS(time.Since(start)))
+		}()
+
+		ctx := firefight.WithSlackInteraction(r.Context(), si)
+		ctx = firefight.WithFireFight(ctx, ff)
+		ctx = firefight.WithLogger(ctx, logger)
 
 		h.ServeHTTP(w, r.WithContext(ctx))
+
+		saveServer(key.String(), ff)
 	}
 	return http.HandlerFunc(fn)
 }
 
-const helpText = `/endpoint/ffstart
+// Metrics serves counters, latencies and game gauges for Prometheus to
+// scrape. Gauges are worked out from the games in memory.
+func Metrics(w http.ResponseWriter, r *http.Request) {
+	games := make(map[string]float64)
+	players := make(map[string]float64)
+	ffServers.Range(func(_, value interface{}) bool {
+		ff := value.(*firefight.FireFight)
+		state := ff.CurrentState().String()
+		games[firefight.Labels("state", state)]++
+
+		stats := ff.Stats()
+		for status, n := range map[string]int{
+			"alive":      stats.Alive,
+			"dead":       stats.Dead,
+			"disputable": stats.Disputable,
+			"defended":   stats.Defended,
+		} {
+			players[firefight.Labels("state", state, "status", status)] += float64(n)
+		}
+		return true
+	})
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+
+	var buf bytes.Buffer
+	commandsTotal.Write(&buf)
+	requestSeconds.Write(&buf)
+	firefight.EventsTotal.Write(&buf)
+	firefight.WriteMetric(&buf, "firefight_games", "Games in memory, by state.", "gauge", games)
+	firefight.WriteMetric(&buf, "firefight_players", "Players in games in memory, by game state and player status.", "gauge", players)
+
+	if _, err := buf.WriteTo(w); err != nil {
+		firefight.Logger(r.Context()).Error("write metrics", "error", err)
+	}
+}
+
+const helpText = `/endpoint/ff
+/endpoint/ffstart
 /endpoint/ffpause
 /endpoint/ffend
+/endpoint/ffreset
+/endpoint/ffadmin
+/endpoint/ffkick
+/endpoint/ffban
+/endpoint/ffunban
 /endpoint/ffjoin
+/endpoint/ffleave
 /endpoint/fftarget
 /endpoint/ffhit
 /endpoint/ffdispute
+/endpoint/ffconfirm
+/endpoint/ffref
 /endpoint/ffdefended
 /endpoint/ffscore
+/interactive
+/metrics
 `
 
 func Index(w http.ResponseWriter, r *http.Request) {
-	fmt.Fprintln(w, helpText)
+	fmt.Fprint(w, helpText)
+}
+
+// AdminCredentials guard the debug routes. Either a bearer token or basic
+// auth may be configured, or both.
+type AdminCredentials struct {
+	Token string
+
+	User     string
+	Password string
+}
+
+func adminCredentials() AdminCredentials {
+	return AdminCredentials{
+		Token:    os.Getenv("FIREFIGHT_ADMIN_TOKEN"),
+		User:     os.Getenv("FIREFIGHT_ADMIN_USER"),
+		Password: os.Getenv("FIREFIGHT_ADMIN_PASSWORD"),
+	}
+}
+
+func secureCompare(given, expected string) bool {
+	return expected != "" && subtle.ConstantTimeCompare([]byte(given), []byte(expected)) == 1
 }
 
-func DebugRoutes() *goji.Mux {
+// adminKey is the context key of the admin's name.
+type adminKey struct{}
+
+// AdminAuth rejects requests without valid admin credentials and stores the
+// admin's name in the context so changes can be attributed.
+//
+// With no credentials configured every request is refused.
+func AdminAuth(creds AdminCredentials) func(http.Handler) http.Handler {
+	basicAuth := creds.User != "" && creds.Password != ""
+	if creds.Token == "" && !basicAuth {
+		slog.Warn("no admin credentials configured, debug routes disabled")
+	}
+
+	return func(h http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			var admin string
+
+			if bearer := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "); secureCompare(bearer, creds.Token) {
+				admin = "token"
+			} else if user, pass, ok := r.BasicAuth(); ok && basicAuth &&
+				secureCompare(user, creds.User) && secureCompare(pass, creds.Password) {
+				admin = user
+			}
+
+			if admin == "" {
+				if basicAuth {
+					w.Header().Set("WWW-Authenticate", `Basic realm="FireFight Debug"`)
+				}
+				http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), adminKey{}, admin)
+			h.ServeHTTP(w, r.WithContext(ctx))
+		}
+		return http.HandlerFunc(fn)
+	}
+}
+
+// apiError is how debug routes report a refused change. Code is stable, see
+// firefight.ErrorCode.
+type apiError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// adminAction forces a change on game ':id', loading it if it's been
+// evicted.
+//
+// 'fn' returns a description of what it did. Every attempt is logged with
+// the admin who made it and the game is saved afterwards.
+// Refused changes are answered with an apiError.
+func adminAction(fn func(admin string, ff *firefight.FireFight, r *http.Request) (string, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := firefight.ParseGameKey(pat.Param(r, "id"))
+		id := key.String()
+
+		lock := gameLock(id)
+		lock.RLock()
+		defer lock.RUnlock()
+
+		ff := loadServer(key)
+		admin, _ := r.Context().Value(adminKey{}).(string)
+
+		action, err := fn(admin, ff, r)
+		logger := firefight.Logger(r.Context()).With(
+			"game", id,
+			"admin", admin,
+			"action", action,
+			"state", ff.CurrentState().String())
+		if err != nil {
+			code := firefight.ErrorCode(err)
+			logger.Warn("admin action failed", "code", code, "error", err)
+
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusConflict)
+			if err := json.NewEncoder(w).Encode(apiError{Code: code, Message: err.Error()}); err != nil {
+				logger.Error("encode response", "error", err)
+			}
+			return
+		}
+
+		logger.Info("admin action")
+		saveServer(id, ff)
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(ff); err != nil {
+			logger.Error("encode response", "error", err)
+		}
+	}
+}
+
+// inWorkspace reports if game 'key' matches the 'team' and 'enterprise'
+// filters in 'q'. Missing filters match everything.
+func inWorkspace(q url.Values, key firefight.GameKey) bool {
+	if team := q.Get("team"); team != "" && key.TeamID != team {
+		return false
+	}
+
+	if enterprise := q.Get("enterprise"); enterprise != "" && key.EnterpriseID != enterprise {
+		return false
+	}
+
+	return true
+}
+
+func DebugRoutes(creds AdminCredentials) *goji.Mux {
 	debugMux := goji.SubMux()
+	debugMux.Use(AdminAuth(creds))
+
+	// Query 'team' and 'enterprise' narrow the list down to a workspace.
 	debugMux.HandleFunc(pat.Get("/"), func(w http.ResponseWriter, r *http.Request) {
 		fmt.Fprintln(w, "<!DOCTYPE html><html><head><title>FireFight Debug</title></head><body>")
-		ffServers.Range(func(key, value interface{}) bool {
-			fmt.Fprintf(w, "<p><a href='./channel/%s'>%s</a></p>\n", key, key)
+		ffServers.Range(func(id, value interface{}) bool {
+			key := firefight.ParseGameKey(id.(string))
+			if !inWorkspace(r.URL.Query(), key) {
+				return true
+			}
+
+			fmt.Fprintf(w, "<p><a href='./channel/%s'>%s</a> <a href='./?team=%s'>%s</a></p>\n",
+				id, key.ChannelID, key.TeamID, key.TeamID)
 			return true
 		})
 		fmt.Fprintln(w, "</body></html>")
 	})
 
+	// Same as the index as JSON, with every game's stats.
+	debugMux.HandleFunc(pat.Get("/games"), func(w http.ResponseWriter, r *http.Request) {
+		games := make(map[string]interface{})
+		ffServers.Range(func(id, value interface{}) bool {
+			if inWorkspace(r.URL.Query(), firefight.ParseGameKey(id.(string))) {
+				games[id.(string)] = value
+			}
+			return true
+		})
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(games); err != nil {
+			firefight.Logger(r.Context()).Error("encode response", "error", err)
+		}
+	})
+
 	debugMux.HandleFunc(pat.Get("/channel/:id"), func(w http.ResponseWriter, r *http.Request) {
 		id := pat.Param(r, "id")
 		ffi, ok := ffServers.Load(id)
@@ -182,7 +999,114 @@ func DebugRoutes() *goji.Mux {
 
 		w.Header().Set("Content-Type", "application/json")
 		if err := json.NewEncoder(w).Encode(ffi); err != nil {
-			log.Println("[Join]", err)
+			firefight.Logger(r.Context()).Error("encode response", "error", err)
+		}
+	})
+
+	debugMux.HandleFunc(pat.Post("/channel/:id/reset"), adminAction(
+		func(admin string, ff *firefight.FireFight, r *http.Request) (string, error) {
+			return "reset", ff.Reset(admin)
+		}))
+
+	debugMux.HandleFunc(pat.Post("/channel/:id/pause"), adminAction(
+		func(admin string, ff *firefight.FireFight, r *http.Request) (string, error) {
+			return "pause", ff.ForcePause(admin)
+		}))
+
+	debugMux.HandleFunc(pat.Post("/channel/:id/end"), adminAction(
+		func(admin string, ff *firefight.FireFight, r *http.Request) (string, error) {
+			_, _, err := ff.ForceEnd(admin)
+			return "end", err
+		}))
+
+	debugMux.HandleFunc(pat.Post("/channel/:id/player/:player/remove"), adminAction(
+		func(admin string, ff *firefight.FireFight, r *http.Request) (string, error) {
+			player := pat.Param(r, "player")
+			return fmt.Sprintf("remove %s", player), ff.RemovePlayer(admin, player)
+		}))
+
+	// Form value 'ruling' is 'uphold' or 'overturn'.
+	debugMux.HandleFunc(pat.Post("/channel/:id/player/:player/resolve"), adminAction(
+		func(admin string, ff *firefight.FireFight, r *http.Request) (string, error) {
+			player := pat.Param(r, "player")
+			ruling := r.FormValue("ruling")
+			action := fmt.Sprintf("resolve %s %q", player, ruling)
+
+			switch ruling {
+			case "uphold":
+				return action, ff.ResolveHit(admin, player, true)
+			case "overturn":
+				return action, ff.ResolveHit(admin, player, false)
+			}
+
+			return action, errors.New("ruling must be uphold or overturn")
+		}))
+
+	// Form value 'score' is the new score.
+	debugMux.HandleFunc(pat.Post("/channel/:id/player/:player/score"), adminAction(
+		func(admin string, ff *firefight.FireFight, r *http.Request) (string, error) {
+			player := pat.Param(r, "player")
+			action := fmt.Sprintf("score %s = %q", player, r.FormValue("score"))
+
+			score, err := strconv.Atoi(r.FormValue("score"))
+			if err != nil {
+				return action, err
+			}
+
+			return action, ff.SetScore(admin, player, score)
+		}))
+
+	// Audit trail of every change to a game.
+	debugMux.HandleFunc(pat.Get("/channel/:id/events"), func(w http.ResponseWriter, r *http.Request) {
+		id := pat.Param(r, "id")
+		ffi, ok := ffServers.Load(id)
+		if !ok {
+			http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+			return
+		}
+
+		events, err := ffi.(*firefight.FireFight).History()
+		if err != nil {
+			firefight.Logger(r.Context()).Error("load history", "error", err)
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(events); err != nil {
+			firefight.Logger(r.Context()).Error("encode response", "error", err)
+		}
+	})
+
+	// Game state rebuilt from the event log, optionally as of ?at=<RFC3339>.
+	debugMux.HandleFunc(pat.Get("/channel/:id/replay"), func(w http.ResponseWriter, r *http.Request) {
+		id := pat.Param(r, "id")
+		ffi, ok := ffServers.Load(id)
+		if !ok {
+			http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+			return
+		}
+
+		var at time.Time
+		if v := r.URL.Query().Get("at"); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+				return
+			}
+			at = t
+		}
+
+		events, err := ffi.(*firefight.FireFight).History()
+		if err != nil {
+			firefight.Logger(r.Context()).Error("load history", "error", err)
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(firefight.ReplayUntil(events, at)); err != nil {
+			firefight.Logger(r.Context()).Error("encode response", "error", err)
 		}
 	})
 
@@ -191,63 +1115,80 @@ func DebugRoutes() *goji.Mux {
 
 // Slack endpoint verification.
 //
-// const Signing_Secret = ""
-//
-// const ChallengeTimeRange = 5 * time.Minute
-//
-// func SlackHeaderHMAC(h http.Header) []byte {
-// 	sigHeader, err := url.ParseQuery(h.Get("X-Slack-Signature"))
-// 	if err != nil {
-// 		return nil
-// 	}
-//
-// 	raw, err := hex.DecodeString(sigHeader.Get("v0"))
-// 	if err != nil {
-// 		return nil
-// 	}
-//
-// 	return raw
-// }
-//
-// func CHAP(w http.ResponseWriter, r *http.Request) {
-// 	slackTimestamp := r.Header.Get("X-Slack-Request-Timestamp")
-// 	epoch, err := strconv.Atoi(slackTimestamp)
-// 	if err != nil {
-// 		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
-// 		return
-// 	}
-//
-// 	reqDelta := time.Now().Sub(time.Unix(int64(epoch), 0))
-// 	if reqDelta < -ChallengeTimeRange || ChallengeTimeRange < reqDelta {
-// 		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
-// 		return
-// 	}
-//
-// 	body, err := ioutil.ReadAll(r.Body)
-// 	if err != nil {
-// 		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
-// 		return
-// 	}
-//
-// 	sig_basestring := fmt.Sprintf("v0:%d:%s", epoch, body)
-// 	mac := hmac.New(sha256.New, []byte(Signing_Secret))
-// 	mac.Write([]byte(sig_basestring))
-// 	expectedMAC := mac.Sum(nil)
-//
-// 	if !hmac.Equal(SlackHeaderHMAC(r.Header), expectedMAC) {
-// 		log.Println("[chap] Bad HMAC")
-// 		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
-// 		return
-// 	}
-//
-// 	var cha struct {
-// 		Challenge string
-// 	}
-// 	if err := json.Unmarshal(body, &cha); err != nil {
-// 		log.Println("[chap]", err)
-// 		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
-// 		return
-// 	}
-//
-// 	fmt.Fprintln(w, cha.Challenge)
-// }
+// https://api.slack.com/authentication/verifying-requests-from-slack
+
+// DefaultSignatureWindow is how far a request timestamp may drift from our
+// clock before it is treated as a replay.
+const DefaultSignatureWindow = 5 * time.Minute
+
+func signatureWindow() time.Duration {
+	return envDuration("FIREFIGHT_SIGNATURE_WINDOW", DefaultSignatureWindow)
+}
+
+// MaxBodySize bounds the request bodies Verify reads. Slack's are a few KB.
+const MaxBodySize = 1 << 20
+
+func SlackHeaderHMAC(h http.Header) []byte {
+	sigHeader, err := url.ParseQuery(h.Get("X-Slack-Signature"))
+	if err != nil {
+		return nil
+	}
+
+	raw, err := hex.DecodeString(sigHeader.Get("v0"))
+	if err != nil {
+		return nil
+	}
+
+	return raw
+}
+
+// Verify rejects requests not signed by Slack with 'secret' or signed more
+// than 'window' away from now, and bodies over MaxBodySize. The body is put
+// back for later middleware.
+func Verify(secret string, window time.Duration) func(http.Handler) http.Handler {
+	return func(h http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			slackTimestamp := r.Header.Get("X-Slack-Request-Timestamp")
+			epoch, err := strconv.ParseInt(slackTimestamp, 10, 64)
+			if err != nil {
+				http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+				return
+			}
+
+			reqDelta := time.Since(time.Unix(epoch, 0))
+			if reqDelta < -window || window < reqDelta {
+				firefight.Logger(r.Context()).Warn("stale timestamp", "delta", reqDelta.String())
+				http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+				return
+			}
+
+			body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, MaxBodySize))
+			r.Body.Close()
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				firefight.Logger(r.Context()).Warn("body too large", "limit", tooLarge.Limit)
+				http.Error(w, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+				return
+			}
+			if err != nil {
+				http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+				return
+			}
+
+			mac := hmac.New(sha256.New, []byte(secret))
+			fmt.Fprintf(mac, "v0:%d:%s", epoch, body)
+			expectedMAC := mac.Sum(nil)
+
+			if !hmac.Equal(SlackHeaderHMAC(r.Header), expectedMAC) {
+				firefight.Logger(r.Context()).Warn("bad signature")
+				http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+				return
+			}
+
+			r.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+			h.ServeHTTP(w, r)
+		}
+		return http.HandlerFunc(fn)
+	}
+}
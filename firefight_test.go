@@ -0,0 +1,71 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestVerify(t *testing.T) {
+	const secret = "8f742231b10e8888abcd99yyyzzz85a5"
+	body := "command=/ffjoin&team_id=T1&channel_id=C1&user_id=U1"
+	now := time.Now().Unix()
+
+	sign := func(secret string, ts int64, body string) string {
+		mac := hmac.New(sha256.New, []byte(secret))
+		fmt.Fprintf(mac, "v0:%d:%s", ts, body)
+		return "v0=" + hex.EncodeToString(mac.Sum(nil))
+	}
+
+	tests := []struct {
+		name      string
+		timestamp string
+		signature string
+		body      string
+		want      int
+	}{
+		{"good signature", strconv.FormatInt(now, 10), sign(secret, now, body), body, http.StatusOK},
+		{"bad signature", strconv.FormatInt(now, 10), sign("not the secret", now, body), body, http.StatusUnauthorized},
+		{"tampered body", strconv.FormatInt(now, 10), sign(secret, now, body), body + "x", http.StatusUnauthorized},
+		{"stale timestamp", strconv.FormatInt(now-600, 10), sign(secret, now-600, body), body, http.StatusUnauthorized},
+		{"future timestamp", strconv.FormatInt(now+600, 10), sign(secret, now+600, body), body, http.StatusUnauthorized},
+		{"no timestamp", "", sign(secret, now, body), body, http.StatusBadRequest},
+		{"body too large", strconv.FormatInt(now, 10), "", strings.Repeat("x", MaxBodySize+1), http.StatusRequestEntityTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			h := Verify(secret, DefaultSignatureWindow)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				data, err := ioutil.ReadAll(r.Body)
+				if err != nil {
+					t.Errorf("reading body: %v", err)
+				}
+				got = string(data)
+			}))
+
+			r := httptest.NewRequest("POST", "/endpoint/ffjoin", strings.NewReader(tt.body))
+			r.Header.Set("X-Slack-Request-Timestamp", tt.timestamp)
+			r.Header.Set("X-Slack-Signature", tt.signature)
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+
+			if w.Code != tt.want {
+				t.Errorf("status %d; want %d", w.Code, tt.want)
+			}
+
+			// The handler still gets the whole body.
+			if tt.want == http.StatusOK && got != tt.body {
+				t.Errorf("handler read %q; want %q", got, tt.body)
+			}
+		})
+	}
+}
@@ -0,0 +1,177 @@
+package firefight
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GameConfig is the rules a game is played by. Set when a game starts.
+type GameConfig struct {
+	HitCooldown       time.Duration
+	DefensiveCooldown time.Duration
+	Shuffle           bool // Shuffle the ring on start. Off keeps join order.
+
+	// Respawn is how long hit players sit out once their hit is final before
+	// rejoining at a random spot in the ring. Zero means hits are for good.
+	Respawn time.Duration
+
+	// Duration ends the game after this long, highest score wins.
+	// Zero plays until one player or team is left standing.
+	Duration time.Duration
+
+	// ConfirmWindow is how long targets have to confirm a hit before it
+	// goes to a ruling. Zero takes the attacker's word for it.
+	ConfirmWindow time.Duration
+}
+
+func DefaultGameConfig() GameConfig {
+	return GameConfig{
+		HitCooldown:       HitCooldown,
+		DefensiveCooldown: DefensiveCooldown,
+		Shuffle:           true,
+	}
+}
+
+const rulesUsage = "Rules: hit-cooldown=<duration> defend-cooldown=<duration> shuffle=on|off " +
+	"respawn=<duration> duration=<duration> confirm=on|off|<duration>"
+
+// ParseGameConfig reads rules like 'hit-cooldown=10m defend-cooldown=1d shuffle=off'
+// on top of the defaults.
+func ParseGameConfig(text string) (GameConfig, error) {
+	return ParseRules(DefaultGameConfig(), text)
+}
+
+// ParseRules is ParseGameConfig on top of 'cfg', e.g. a workspace's rules.
+func ParseRules(cfg GameConfig, text string) (GameConfig, error) {
+	for _, field := range strings.Fields(text) {
+		kv := strings.SplitN(field, "=", 2)
+		if len(kv) != 2 {
+			return cfg, &ErrBadRule{Value: field}
+		}
+
+		key, value := strings.ToLower(kv[0]), kv[1]
+
+		var err error
+		switch key {
+		case "hit-cooldown":
+			cfg.HitCooldown, err = parseRuleDuration(value)
+		case "defend-cooldown", "defensive-cooldown":
+			cfg.DefensiveCooldown, err = parseRuleDuration(value)
+		case "shuffle":
+			cfg.Shuffle, err = parseRuleSwitch(value)
+		case "respawn":
+			cfg.Respawn, err = parseRuleDuration(value)
+		case "duration":
+			cfg.Duration, err = parseRuleDuration(value)
+		case "confirm":
+			cfg.ConfirmWindow, err = parseConfirmWindow(value)
+		default:
+			return cfg, &ErrBadRule{Rule: key, Value: value}
+		}
+
+		if err != nil {
+			return cfg, &ErrBadRule{Rule: key, Value: value, Err: err}
+		}
+	}
+
+	if cfg.Respawn > 0 && cfg.Duration == 0 {
+		return cfg, ErrRespawnNeedsDuration
+	}
+
+	return cfg, nil
+}
+
+// parseRuleDuration is time.ParseDuration plus whole days, e.g. '7d'.
+func parseRuleDuration(v string) (time.Duration, error) {
+	var d time.Duration
+	var err error
+
+	if days := strings.TrimSuffix(v, "d"); days != v {
+		var n int
+		n, err = strconv.Atoi(days)
+		d = time.Duration(n) * 24 * time.Hour
+	} else {
+		d, err = time.ParseDuration(v)
+	}
+
+	if err != nil {
+		return 0, fmt.Errorf("not a duration")
+	}
+
+	if d < 0 {
+		return 0, fmt.Errorf("can't be negative")
+	}
+
+	return d, nil
+}
+
+func parseRuleSwitch(v string) (bool, error) {
+	switch strings.ToLower(v) {
+	case "on", "true", "yes":
+		return true, nil
+	case "off", "false", "no":
+		return false, nil
+	}
+
+	return false, fmt.Errorf("expected on or off")
+}
+
+// parseConfirmWindow takes a window or on/off.
+func parseConfirmWindow(v string) (time.Duration, error) {
+	if on, err := parseRuleSwitch(v); err == nil {
+		if on {
+			return DefaultConfirmWindow, nil
+		}
+		return 0, nil
+	}
+
+	d, err := parseRuleDuration(v)
+	if err != nil {
+		return 0, fmt.Errorf("expected on, off or a duration")
+	}
+
+	return d, nil
+}
+
+func formatRuleDuration(d time.Duration) string {
+	if d >= 24*time.Hour && d%(24*time.Hour) == 0 {
+		return fmt.Sprintf("%dd", d/(24*time.Hour))
+	}
+
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+
+	return s
+}
+
+// String formats the rules the same way ParseGameConfig reads them.
+func (c GameConfig) String() string {
+	shuffle := "on"
+	if !c.Shuffle {
+		shuffle = "off"
+	}
+
+	rules := fmt.Sprintf("hit-cooldown=%s defend-cooldown=%s shuffle=%s",
+		formatRuleDuration(c.HitCooldown), formatRuleDuration(c.DefensiveCooldown), shuffle)
+
+	if c.Respawn > 0 {
+		rules += " respawn=" + formatRuleDuration(c.Respawn)
+	}
+
+	if c.Duration > 0 {
+		rules += " duration=" + formatRuleDuration(c.Duration)
+	}
+
+	if c.ConfirmWindow > 0 {
+		rules += " confirm=" + formatRuleDuration(c.ConfirmWindow)
+	}
+
+	return rules
+}
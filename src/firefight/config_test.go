@@ -0,0 +1,56 @@
+package firefight
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseRules(t *testing.T) {
+	workspace, err := ParseGameConfig("shuffle=off confirm=on hit-cooldown=10m")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		text string
+		want GameConfig
+	}{
+		{"", workspace},
+		{"hit-cooldown=1m", GameConfig{HitCooldown: time.Minute, DefensiveCooldown: DefensiveCooldown,
+			ConfirmWindow: DefaultConfirmWindow}},
+		{"confirm=off shuffle=on", GameConfig{HitCooldown: 10 * time.Minute, DefensiveCooldown: DefensiveCooldown,
+			Shuffle: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			got, err := ParseRules(workspace, tt.text)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got != tt.want {
+				t.Errorf("got %s; want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStartOnWorkspaceRules(t *testing.T) {
+	ff := New()
+	ff.SetDefaults(GameConfig{HitCooldown: time.Minute, DefensiveCooldown: time.Minute, ConfirmWindow: time.Minute})
+	for _, id := range []string{"A", "B"} {
+		if err := ff.Join(id, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := Start(ff, &SlackCmd{UserID: "A", Text: "hit-cooldown=5m"}); err != nil {
+		t.Fatal(err)
+	}
+
+	want := GameConfig{HitCooldown: 5 * time.Minute, DefensiveCooldown: time.Minute, ConfirmWindow: time.Minute}
+	if got := ff.Rules(); got != want {
+		t.Errorf("rules %s; want %s", got, want)
+	}
+}
@@ -0,0 +1,92 @@
+package firefight
+
+import "time"
+
+// Confirmed hits
+//
+// With GameConfig.ConfirmWindow set, a reported hit only claims the target.
+// The claim stays pending until the target confirms it, which makes it a
+// final hit, or disputes it. Claims that are disputed or go unanswered
+// within the window wait for a ruling. Attackers can't claim another hit
+// while one is pending.
+//
+// Without referees there is nobody to rule. A disputed claim is dropped
+// right away and an unanswered one stands once the window runs out.
+
+// DefaultConfirmWindow is the confirm window for 'confirm=on'.
+const DefaultConfirmWindow = 10 * time.Minute
+
+// findPendingBy returns the array index of the player 'id' has a pending
+// claim on.
+func (pl PlayerList) findPendingBy(id string) int {
+	for i, p := range pl {
+		if p.PendingBy == id {
+			return i
+		}
+	}
+
+	return -1 // no claim
+}
+
+// awaitingRuling reports if the claim on 'p' was disputed or went
+// unanswered.
+func (p Player) awaitingRuling(now time.Time) bool {
+	return p.PendingBy != "" && !now.Before(p.PendingTimeout)
+}
+
+// acceptUnanswered makes every claim that went unanswered by 'now' a final
+// hit, unless there are referees to rule on them.
+//
+// ff.mu must be held.
+func (ff *FireFight) acceptUnanswered(now time.Time) {
+	if len(ff.Referees) > 0 {
+		return
+	}
+
+	for _, p := range ff.Players {
+		if p.awaitingRuling(now) {
+			ff.record(Event{Time: now, Type: EventAccept, Player: p.ID}) // try again on the next check
+		}
+	}
+}
+
+// claimHit records a pending hit by player at 'index' on 'target'.
+//
+// ff.mu must be held.
+func (ff *FireFight) claimHit(now time.Time, index int, target *Player) (Player, error) {
+	id := ff.Players[index].ID
+
+	if tindex := ff.Players.findPendingBy(id); tindex != -1 {
+		return Player{}, &ErrClaimPending{Target: ff.Players[tindex].ID}
+	}
+
+	if target.PendingBy != "" {
+		return Player{}, ErrClaimTaken
+	}
+
+	e := Event{Time: now, Type: EventClaim, Player: id, Target: target.ID}
+	if err := ff.record(e); err != nil {
+		return Player{}, err
+	}
+
+	return *target, nil
+}
+
+// applyAccept turns the claim on player at 'index' into a final hit.
+//
+// ff.mu must be held.
+func (ff *FireFight) applyAccept(now time.Time, index int) {
+	p := &ff.Players[index]
+	attacker := p.PendingBy
+
+	p.PendingBy = ""
+	p.PendingTimeout = time.Time{}
+
+	p.Hit = true
+	p.HitTimeout = ff.gameTime(now) // nothing left to dispute
+
+	if aindex := ff.find(attacker); aindex != -1 {
+		p.HitBy = attacker
+		ff.Players[aindex].Score++
+	}
+}
@@ -0,0 +1,121 @@
+package firefight
+
+import (
+	"errors"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestClaimWithoutReferees(t *testing.T) {
+	cfg := DefaultGameConfig()
+	cfg.ConfirmWindow = DefaultConfirmWindow
+
+	tests := []struct {
+		name      string
+		players   []string
+		after     time.Duration
+		lateTimer bool // Timer hasn't fired yet when the answer comes in.
+		answer    func(ff *FireFight) error
+		wantHit   bool
+		winner    string
+	}{
+		{
+			name:    "unanswered stands",
+			players: []string{"A", "B", "C"},
+			after:   DefaultConfirmWindow,
+			wantHit: true,
+		},
+		{
+			name:    "unanswered ends the game",
+			players: []string{"A", "B"},
+			after:   DefaultConfirmWindow,
+			wantHit: true,
+			winner:  "A",
+		},
+		{
+			name:      "confirmed after the window",
+			players:   []string{"A", "B", "C"},
+			after:     DefaultConfirmWindow + time.Second,
+			lateTimer: true,
+			answer:    func(ff *FireFight) error { return ff.ConfirmHit("B") },
+			wantHit:   true,
+		},
+		{
+			name:    "disputed is dropped",
+			players: []string{"A", "B", "C"},
+			answer: func(ff *FireFight) error {
+				if revived, err := ff.DisputeHit("B"); err != nil || !revived {
+					return errors.New("claim not dropped")
+				}
+				return nil
+			},
+			after: time.Minute,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ff, clock := newTestGameWith(t, cfg, tt.players...)
+			if _, err := ff.ReportHit("A"); err != nil {
+				t.Fatal(err)
+			}
+
+			if tt.lateTimer {
+				ff.mu.Lock()
+				ff.timer.Stop()
+				ff.mu.Unlock()
+			}
+
+			clock.Advance(tt.after)
+
+			if tt.answer != nil {
+				if err := tt.answer(ff); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			ff.mu.RLock()
+			defer ff.mu.RUnlock()
+
+			b := ff.Players[ff.find("B")]
+			if b.PendingBy != "" || b.Hit != tt.wantHit {
+				t.Errorf("B: pending by %q, hit %t; want settled, hit %t", b.PendingBy, b.Hit, tt.wantHit)
+			}
+
+			if ff.Winner != tt.winner {
+				t.Errorf("winner %q; want %q", ff.Winner, tt.winner)
+			}
+		})
+	}
+}
+
+// Run with -race: the reply to a claim used to be read from the live ring
+// after the game was unlocked, while the target could already confirm.
+func TestClaimReplyRace(t *testing.T) {
+	cfg := DefaultGameConfig()
+	cfg.ConfirmWindow = DefaultConfirmWindow
+
+	for i := 0; i < 100; i++ {
+		ff, _ := newTestGameWith(t, cfg, "A", "B", "C")
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for ff.ConfirmHit("B") != nil {
+				runtime.Gosched()
+			}
+		}()
+
+		resp, err := ReportHit(ff, &SlackCmd{TeamID: "T1", ChannelID: "C1", UserID: "A"})
+		<-done
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !strings.Contains(resp.Text, "claims a hit on <@B>") {
+			t.Fatalf("replied %q; want the claim", resp.Text)
+		}
+	}
+}
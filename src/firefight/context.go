@@ -0,0 +1,124 @@
+package firefight
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+)
+
+// Request context
+//
+// Middleware puts the game, the slash command or button click, and the
+// request's logger in the context. Handlers get them back with the accessors
+// below, never by key.
+
+type contextKey int
+
+const (
+	fireFightKey contextKey = iota
+	slackCmdKey
+	slackInteractionKey
+	loggerKey
+)
+
+// WithFireFight returns a copy of 'ctx' carrying game 'ff'.
+func WithFireFight(ctx context.Context, ff *FireFight) context.Context {
+	return context.WithValue(ctx, fireFightKey, ff)
+}
+
+// FireFightFrom returns the game in 'ctx', if any.
+func FireFightFrom(ctx context.Context) (*FireFight, bool) {
+	ff, ok := ctx.Value(fireFightKey).(*FireFight)
+	return ff, ok
+}
+
+// WithSlackCmd returns a copy of 'ctx' carrying slash command 'scmd'.
+func WithSlackCmd(ctx context.Context, scmd *SlackCmd) context.Context {
+	return context.WithValue(ctx, slackCmdKey, scmd)
+}
+
+// SlackCmdFrom returns the slash command in 'ctx', if any.
+func SlackCmdFrom(ctx context.Context) (*SlackCmd, bool) {
+	scmd, ok := ctx.Value(slackCmdKey).(*SlackCmd)
+	return scmd, ok
+}
+
+// WithSlackInteraction returns a copy of 'ctx' carrying button click 'si'.
+func WithSlackInteraction(ctx context.Context, si *SlackInteraction) context.Context {
+	return context.WithValue(ctx, slackInteractionKey, si)
+}
+
+// SlackInteractionFrom returns the button click in 'ctx', if any.
+func SlackInteractionFrom(ctx context.Context) (*SlackInteraction, bool) {
+	si, ok := ctx.Value(slackInteractionKey).(*SlackInteraction)
+	return si, ok
+}
+
+// WithLogger returns a copy of 'ctx' carrying 'l'.
+func WithLogger(ctx context.Context, l *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey, l)
+}
+
+// Logger returns the request's logger, or the default one outside requests.
+func Logger(ctx context.Context) *slog.Logger {
+	if l, ok := ctx.Value(loggerKey).(*slog.Logger); ok {
+		return l
+	}
+
+	return slog.Default()
+}
+
+// CommandFunc answers slash command 'scmd' for game 'ff'. An error is shown
+// to the sender alone. Call it directly to test a command without HTTP.
+type CommandFunc func(ff *FireFight, scmd *SlackCmd) (SlackResponse, error)
+
+// NamedCommand is a CommandFunc routed as command 'Name', see Named.
+//
+// As an http.Handler it takes the game and command from the context and
+// writes the answer as JSON.
+type NamedCommand struct {
+	Name string
+	Func CommandFunc
+}
+
+// Named routes 'fn' as command 'name', e.g. "hit" for '/ffhit'. The name
+// picks the wording of refusals and labels logs and metrics. Command, which
+// serves '/ff', is named "" and goes by its subcommand instead.
+func Named(name string, fn CommandFunc) NamedCommand {
+	return NamedCommand{Name: name, Func: fn}
+}
+
+// CommandName names the command 'scmd' runs, e.g. "hit" for both '/ffhit' and
+// '/ff hit'.
+func (c NamedCommand) CommandName(scmd *SlackCmd) string {
+	if c.Name == "" {
+		return subcommandName(scmd.Text)
+	}
+
+	return c.Name
+}
+
+func (c NamedCommand) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ff, ok := FireFightFrom(r.Context())
+	if !ok {
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+
+	scmd, ok := SlackCmdFrom(r.Context())
+	if !ok {
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+
+	data, err := c.Func(ff, scmd)
+	if err != nil {
+		data = refuse(r.Context(), c.CommandName(scmd), err)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(data); err != nil {
+		Logger(r.Context()).Error("encode response", "error", err)
+	}
+}
@@ -0,0 +1,101 @@
+package firefight
+
+import (
+	"fmt"
+	"time"
+)
+
+// Deathmatch
+//
+// With GameConfig.Respawn set, hit players come back once their hit is final
+// and the respawn delay has passed. They rejoin the ring at a random spot,
+// which also hands them a new hunter and target. The clock ends the game.
+
+// respawnAt returns when hit player 'p' comes back, if ever.
+//
+// ff.mu must be held.
+func (ff *FireFight) respawnAt(p Player) (time.Time, bool) {
+	if !p.Hit || p.Disputed || ff.Config.Respawn <= 0 {
+		return time.Time{}, false
+	}
+
+	return p.HitTimeout.Add(ff.Config.Respawn), true
+}
+
+// respawnOrder returns the ring order with the player at 'index' moved to
+// a random spot.
+func (pl PlayerList) respawnOrder(index int) []string {
+	ids := pl.ids()
+	id := ids[index]
+
+	return insertRandom(append(ids[:index], ids[index+1:]...), id)
+}
+
+// respawn brings back every player due by 'now' and returns announcements.
+//
+// ff.mu must be held.
+func (ff *FireFight) respawn(now time.Time) []string {
+	var due []string
+	for _, p := range ff.Players {
+		if t, ok := ff.respawnAt(p); ok && !t.After(now) {
+			due = append(due, p.ID)
+		}
+	}
+
+	var notices []string
+	for _, id := range due {
+		index := ff.find(id)
+		if index == -1 {
+			continue
+		}
+
+		e := Event{Time: now, Type: EventRespawn, Player: id, Order: ff.Players.respawnOrder(index)}
+		if err := ff.record(e); err != nil {
+			continue // try again next time
+		}
+
+		notices = append(notices, fmt.Sprintf("<@%s> respawned. Back in the fight!", id))
+	}
+
+	return notices
+}
+
+// timeUp reports if a timed game has run out of time.
+//
+// ff.mu must be held.
+func (ff *FireFight) timeUp(now time.Time) bool {
+	return ff.State == StateActive && !ff.EndsAt.IsZero() && !now.Before(ff.EndsAt)
+}
+
+// finishOnPoints ends a timed game. The highest score, or team total, wins.
+// Players who forfeited can't win.
+//
+// ff.mu must be held.
+func (ff *FireFight) finishOnPoints(now time.Time) string {
+	e := Event{Time: now, Type: EventFinish}
+	if teams := teamScores(ff.standings(), ""); len(teams) > 0 {
+		e.Team = teams[0].Team
+	} else {
+		for _, p := range ff.scoreboard() {
+			if !p.Forfeited {
+				e.Player = p.ID
+				break
+			}
+		}
+	}
+
+	if err := ff.record(e); err != nil {
+		return "" // try again on the next change
+	}
+
+	switch {
+	case e.Team != "":
+		return fmt.Sprintf("[FireFight Over] Time's up! Team %s wins!\n%s%s", e.Team,
+			teamScoreboardText(teamScores(ff.standings(), e.Team)), scoreboardText(ff.scoreboard()))
+	case e.Player != "":
+		return fmt.Sprintf("[FireFight Over] Time's up! <@%s> wins!\n%s",
+			e.Player, scoreboardText(ff.scoreboard()))
+	}
+
+	return "[FireFight Over] Time's up! Nobody scored."
+}
@@ -0,0 +1,145 @@
+package firefight
+
+import (
+	"errors"
+	"time"
+)
+
+// Errors
+//
+// Every move the game refuses comes back as one of the errors below, so
+// callers can branch with errors.Is and errors.As. Each has a code that API
+// clients can rely on. What players are told lives in messages.go, Error()
+// is the default wording.
+
+// Error is a refused move with nothing more to say than its code.
+type Error struct {
+	code string
+}
+
+func (e *Error) Error() string { return Message(e, "") }
+
+// Code is stable, e.g. "no_active_game".
+func (e *Error) Code() string { return e.code }
+
+var (
+	ErrNoActiveGame   = &Error{"no_active_game"}
+	ErrGamePaused     = &Error{"game_paused"}
+	ErrGameOver       = &Error{"game_over"}
+	ErrGameInProgress = &Error{"game_in_progress"}
+	ErrRulesLocked    = &Error{"rules_locked"} // Rules can't change on unpause.
+
+	ErrNotPlayer     = &Error{"not_player"}
+	ErrAlreadyJoined = &Error{"already_joined"}
+	ErrBanned        = &Error{"banned"}
+	ErrForfeited     = &Error{"forfeited"}
+	ErrTeamRequired  = &Error{"team_required"}
+	ErrNoTeams       = &Error{"no_teams"}
+	ErrBadTeamName   = &Error{"bad_team_name"}
+	ErrTooFewTeams   = &Error{"too_few_teams"}
+	ErrTooFewPlayers = &Error{"too_few_players"}
+
+	ErrDead           = &Error{"dead"}
+	ErrNoTarget       = &Error{"no_target"}
+	ErrLastStanding   = &Error{"last_standing"}
+	ErrTargetDisputed = &Error{"target_disputed"}
+	ErrClaimTaken     = &Error{"claim_taken"} // Someone else claimed the target first.
+	ErrAlreadyHit     = &Error{"already_hit"}
+	ErrNotHunted      = &Error{"not_hunted"}
+	ErrNotHit         = &Error{"not_hit"}
+	ErrAwaitingRuling = &Error{"awaiting_ruling"}
+	ErrDisputeExpired = &Error{"dispute_expired"}
+	ErrHitFinal       = &Error{"hit_final"}
+	ErrStaleHit       = &Error{"stale_hit"} // Answering a hit that's since been settled or replaced.
+
+	ErrAdminOnly   = &Error{"admin_only"}
+	ErrOwnerOnly   = &Error{"owner_only"}
+	ErrRefereeOnly = &Error{"referee_only"}
+	ErrNoOwner     = &Error{"no_owner"}
+	ErrIsOwner     = &Error{"is_owner"} // The owner can't be kicked, banned or demoted.
+
+	ErrWorkspaceAdminOnly = &Error{"workspace_admin_only"} // Moderating a game nobody owns.
+
+	ErrLastReferee          = &Error{"last_referee"} // Open disputes need a referee.
+	ErrOwnFight             = &Error{"own_fight"}
+	ErrNoRuling             = &Error{"no_ruling"} // No hit waiting on a ruling.
+	ErrNoMention            = &Error{"no_mention"}
+	ErrRespawnNeedsDuration = &Error{"respawn_needs_duration"}
+
+	// Used with UserError.
+	ErrAlreadyAdmin   = &Error{"already_admin"}
+	ErrNotAdmin       = &Error{"not_admin"}
+	ErrAlreadyBanned  = &Error{"already_banned"}
+	ErrNotBanned      = &Error{"not_banned"}
+	ErrAlreadyReferee = &Error{"already_referee"}
+	ErrNotReferee     = &Error{"not_referee"}
+	ErrNoDispute      = &Error{"no_dispute"}
+)
+
+// UserError is 'Err' about a user other than the sender, e.g. kicking
+// someone who isn't playing.
+type UserError struct {
+	Err  *Error
+	User string
+}
+
+func (e *UserError) Error() string { return Message(e, "") }
+func (e *UserError) Code() string  { return e.Err.Code() }
+func (e *UserError) Unwrap() error { return e.Err }
+
+// ErrOnCooldown is an attack before a cooldown ran out. Either the target's
+// hit cooldown or, if Defensive, the attacker's own defensive cooldown.
+type ErrOnCooldown struct {
+	Remaining time.Duration
+	Defensive bool
+}
+
+func (e *ErrOnCooldown) Error() string { return Message(e, "") }
+
+func (e *ErrOnCooldown) Code() string {
+	if e.Defensive {
+		return "defensive_cooldown"
+	}
+
+	return "on_cooldown"
+}
+
+// ErrRespawning is a hit player asking for a target before they respawn.
+type ErrRespawning struct {
+	Remaining time.Duration
+}
+
+func (e *ErrRespawning) Error() string { return Message(e, "") }
+func (e *ErrRespawning) Code() string  { return "respawning" }
+
+// ErrClaimPending is a new claim while the attacker's last one on Target is
+// still waiting on confirmation.
+type ErrClaimPending struct {
+	Target string
+}
+
+func (e *ErrClaimPending) Error() string { return Message(e, "") }
+func (e *ErrClaimPending) Code() string  { return "claim_pending" }
+
+// ErrBadRule is a rule that doesn't parse. Without Rule, Value isn't
+// rule=value at all. Without Err, Rule is unknown.
+type ErrBadRule struct {
+	Rule  string
+	Value string
+	Err   error
+}
+
+func (e *ErrBadRule) Error() string { return Message(e, "") }
+func (e *ErrBadRule) Code() string  { return "bad_rule" }
+func (e *ErrBadRule) Unwrap() error { return e.Err }
+
+// ErrorCode returns the code of 'err' for API clients. Errors that aren't
+// refused moves, e.g. a failed write, are "internal".
+func ErrorCode(err error) string {
+	var coded interface{ Code() string }
+	if errors.As(err, &coded) {
+		return coded.Code()
+	}
+
+	return "internal"
+}
@@ -0,0 +1,441 @@
+package firefight
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+type EventType string
+
+const (
+	EventJoin    EventType = "join"
+	EventStart   EventType = "start" // New game or unpause. Player owns new games.
+	EventPause   EventType = "pause"
+	EventEnd     EventType = "end"
+	EventReset   EventType = "reset"
+	EventHit     EventType = "hit"
+	EventDispute EventType = "dispute"
+	EventConfirm EventType = "confirm"
+	EventDefend  EventType = "defend"
+	EventRemove  EventType = "remove"
+	EventScore   EventType = "score"
+	EventFinish  EventType = "finish"
+	EventRespawn EventType = "respawn"
+	EventLeave   EventType = "leave"
+
+	// Confirmed hits.
+	EventClaim    EventType = "claim"
+	EventAccept   EventType = "accept"   // Claim or disputed hit confirmed or upheld.
+	EventReject   EventType = "reject"   // Claim disputed, waits for a ruling.
+	EventOverturn EventType = "overturn" // Claim dropped or hit taken back by a ruling.
+
+	// Permissions.
+	EventAdmin   EventType = "admin"   // Target named admin.
+	EventUnadmin EventType = "unadmin" // Target no longer admin.
+	EventBan     EventType = "ban"     // Target removed and kept out.
+	EventUnban   EventType = "unban"   // Target let back in.
+
+	// Referees.
+	EventReferee   EventType = "referee"   // Target named referee.
+	EventUnreferee EventType = "unreferee" // Target no longer referee.
+	EventAppeal    EventType = "appeal"    // Hit disputed, waits for a ruling.
+)
+
+// Event is a single change to a game.
+//
+// Events carry everything needed to apply them again, so replaying a game's
+// log from the start rebuilds the exact same FireFight. That includes the
+// ring order picked by a shuffle.
+type Event struct {
+	Time time.Time
+	Type EventType
+
+	Player string   `json:",omitempty"` // Acting player.
+	Target string   `json:",omitempty"` // Player acted upon.
+	Team   string   `json:",omitempty"` // Team joined or won.
+	Order  []string `json:",omitempty"` // Ring order after a shuffle or a move.
+	Score  int      `json:",omitempty"` // New score of Target.
+
+	Config *GameConfig `json:",omitempty"` // Rules of a new game.
+
+	Admin   string `json:",omitempty"` // Set when an admin forced the change.
+	Referee string `json:",omitempty"` // Set when a referee ruled.
+}
+
+// apply mutates the game according to 'e'.
+//
+// Events are only recorded after the move was validated so this never fails.
+// Events naming unknown players are ignored.
+//
+// ff.mu must be held.
+func (ff *FireFight) apply(e Event) {
+	defer ff.reindex()
+
+	if e.Time.After(ff.Updated) {
+		ff.Updated = e.Time
+	}
+
+	switch e.Type {
+	case EventJoin:
+		ff.Players = append(ff.Players, Player{ID: e.Player, Team: e.Team})
+		if e.Order != nil {
+			ff.Players = ff.Players.reorder(e.Order)
+		}
+
+	case EventStart:
+		if ff.State == StateFinished {
+			ff.Players.rematch()
+			ff.Forfeited = nil
+			ff.Winner = ""
+			ff.WinningTeam = ""
+		}
+
+		if ff.Owner == "" {
+			ff.Owner = e.Player
+		}
+
+		if e.Config != nil {
+			ff.Config = *e.Config
+
+			ff.EndsAt = time.Time{}
+			if ff.Config.Duration > 0 {
+				ff.EndsAt = e.Time.Add(ff.Config.Duration)
+			}
+		}
+
+		if e.Order != nil {
+			ff.Players = ff.Players.reorder(e.Order)
+		}
+
+		if ff.State == StatePaused {
+			paused := e.Time.Sub(ff.PausedAt)
+			ff.Players.extendCooldowns(ff.PausedAt, paused)
+			if ff.EndsAt.After(ff.PausedAt) {
+				ff.EndsAt = ff.EndsAt.Add(paused)
+			}
+			ff.PausedAt = time.Time{}
+		}
+		ff.State = StateActive
+
+	case EventPause:
+		ff.State = StatePaused
+		ff.PausedAt = e.Time
+
+	case EventEnd, EventReset:
+		ff.Players = ff.Players[0:0]
+		ff.Owner = ""
+		ff.Admins = nil
+		ff.Referees = nil
+		ff.Forfeited = nil
+		ff.State = StateIdle
+		ff.PausedAt = time.Time{}
+		ff.EndsAt = time.Time{}
+		ff.Winner = ""
+		ff.WinningTeam = ""
+
+	case EventFinish:
+		ff.State = StateFinished
+		ff.Winner = e.Player
+		ff.WinningTeam = e.Team
+
+	case EventHit:
+		index := ff.find(e.Player)
+		tindex := ff.find(e.Target)
+		if index == -1 || tindex == -1 {
+			return
+		}
+
+		target := &ff.Players[tindex]
+		target.HitTimeout = ff.gameTime(e.Time).Add(ff.Config.HitCooldown)
+		target.HitBy = e.Player
+		target.HitAt = e.Time
+		target.Hit = true
+
+		ff.Players[index].Score++
+
+	case EventDispute:
+		if index := ff.find(e.Player); index != -1 {
+			ff.revive(index)
+		}
+
+	case EventConfirm:
+		if index := ff.find(e.Player); index != -1 {
+			ff.Players[index].HitTimeout = ff.gameTime(e.Time)
+		}
+
+	case EventDefend:
+		hindex := ff.find(e.Target)
+		if hindex == -1 {
+			return
+		}
+
+		ff.Players[hindex].DefensiveTimeout = e.Time.Add(ff.Config.DefensiveCooldown)
+
+	case EventClaim:
+		if tindex := ff.find(e.Target); tindex != -1 {
+			ff.Players[tindex].PendingBy = e.Player
+			ff.Players[tindex].HitAt = e.Time
+			ff.Players[tindex].PendingTimeout = e.Time.Add(ff.Config.ConfirmWindow)
+		}
+
+	case EventAccept:
+		index := ff.find(e.Player)
+		if index == -1 {
+			return
+		}
+
+		if ff.Players[index].Disputed {
+			ff.Players[index].Disputed = false
+			ff.Players[index].HitTimeout = ff.gameTime(e.Time)
+			return
+		}
+
+		ff.applyAccept(e.Time, index)
+
+	case EventReject:
+		if index := ff.find(e.Player); index != -1 {
+			ff.Players[index].PendingTimeout = ff.gameTime(e.Time)
+		}
+
+	case EventOverturn:
+		index := ff.find(e.Player)
+		if index == -1 {
+			return
+		}
+
+		if ff.Players[index].Disputed {
+			ff.revive(index)
+			return
+		}
+
+		ff.Players[index].PendingBy = ""
+		ff.Players[index].PendingTimeout = time.Time{}
+
+	case EventAppeal:
+		if index := ff.find(e.Player); index != -1 {
+			ff.Players[index].Disputed = true
+		}
+
+	case EventAdmin:
+		ff.Admins = append(ff.Admins, e.Target)
+
+	case EventUnadmin:
+		for i, id := range ff.Admins {
+			if id == e.Target {
+				ff.Admins = append(ff.Admins[:i:i], ff.Admins[i+1:]...)
+				break
+			}
+		}
+
+	case EventBan:
+		ff.Banned = append(ff.Banned, e.Target)
+
+		for i, id := range ff.Admins {
+			if id == e.Target {
+				ff.Admins = append(ff.Admins[:i:i], ff.Admins[i+1:]...)
+				break
+			}
+		}
+
+		if index := ff.find(e.Target); index != -1 {
+			ff.Players = ff.Players.remove(index)
+		}
+
+	case EventUnban:
+		for i, id := range ff.Banned {
+			if id == e.Target {
+				ff.Banned = append(ff.Banned[:i:i], ff.Banned[i+1:]...)
+				break
+			}
+		}
+
+	case EventReferee:
+		ff.Referees = append(ff.Referees, e.Target)
+
+	case EventUnreferee:
+		for i, id := range ff.Referees {
+			if id == e.Target {
+				ff.Referees = append(ff.Referees[:i:i], ff.Referees[i+1:]...)
+				break
+			}
+		}
+
+	case EventRespawn:
+		index := ff.find(e.Player)
+		if index == -1 {
+			return
+		}
+
+		p := &ff.Players[index]
+		p.Hit = false
+		p.HitBy = ""
+		p.HitTimeout = time.Time{}
+		p.Disputed = false
+
+		if e.Order != nil {
+			ff.Players = ff.Players.reorder(e.Order)
+		}
+
+	case EventLeave:
+		index := ff.find(e.Player)
+		if index == -1 {
+			return
+		}
+
+		if ff.State != StateIdle {
+			p := ff.Players[index]
+			ff.Forfeited = append(ff.Forfeited, Player{ID: p.ID, Team: p.Team, Score: p.Score, Forfeited: true})
+		}
+
+		ff.Players = ff.Players.remove(index)
+
+	case EventRemove:
+		if index := ff.find(e.Target); index != -1 {
+			ff.Players = ff.Players.remove(index)
+		}
+
+	case EventScore:
+		if index := ff.find(e.Target); index != -1 {
+			ff.Players[index].Score = e.Score
+		}
+	}
+}
+
+// History returns every event recorded for this game.
+// Games without an event log have no history.
+func (ff *FireFight) History() ([]Event, error) {
+	ff.mu.RLock()
+	l := ff.events
+	ff.mu.RUnlock()
+
+	if l == nil {
+		return nil, nil
+	}
+
+	return l.Events()
+}
+
+// CatchUp applies the events in 'l' recorded after the game's last change,
+// e.g. those a crash kept out of its last snapshot. Returns how many.
+// Events read before an error, e.g. a line torn by the crash, still count.
+func (ff *FireFight) CatchUp(l EventLog) (int, error) {
+	events, err := l.Events()
+
+	ff.mu.Lock()
+	defer ff.mu.Unlock()
+
+	since, n := ff.Updated, 0
+	for _, e := range events {
+		if e.Time.After(since) {
+			ff.apply(e)
+			n++
+		}
+	}
+
+	return n, err
+}
+
+// Replay rebuilds a game from its full event log.
+func Replay(events []Event, opts ...Option) *FireFight {
+	return ReplayUntil(events, time.Time{}, opts...)
+}
+
+// ReplayUntil rebuilds a game as it was at time 't'.
+// A zero 't' replays every event.
+func ReplayUntil(events []Event, t time.Time, opts ...Option) *FireFight {
+	ff := New(opts...)
+	if len(events) > 0 {
+		ff.Created = events[0].Time
+		ff.Updated = ff.Created
+	}
+
+	for _, e := range events {
+		if !t.IsZero() && e.Time.After(t) {
+			break
+		}
+
+		ff.apply(e)
+	}
+
+	return ff
+}
+
+// EventLog is an append-only record of a single game.
+type EventLog interface {
+	Append(e Event) error
+	Events() ([]Event, error)
+}
+
+// FileEventLog stores events as JSON lines in a single file.
+type FileEventLog struct {
+	Path string
+
+	mu sync.Mutex
+}
+
+func NewFileEventLog(path string) *FileEventLog {
+	return &FileEventLog{Path: path}
+}
+
+// Append writes 'e' and syncs it to disk before returning.
+func (l *FileEventLog) Append(e Event) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(l.Path), 0755); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(l.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// Events reads back the whole log. A missing file is an empty log.
+func (l *FileEventLog) Events() ([]Event, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	f, err := os.Open(l.Path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var events []Event
+	dec := json.NewDecoder(bufio.NewReader(f))
+	for dec.More() {
+		var e Event
+		if err := dec.Decode(&e); err != nil {
+			return events, err
+		}
+
+		events = append(events, e)
+	}
+
+	return events, nil
+}
@@ -0,0 +1,118 @@
+package firefight
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// newLoggedGame is newTestGameWith recording to a file event log in a
+// temp dir.
+func newLoggedGame(t *testing.T, cfg GameConfig, ids ...string) (*FireFight, *FakeClock, EventLog) {
+	t.Helper()
+
+	clock := NewFakeClock(epoch)
+	l := NewFileEventLog(filepath.Join(t.TempDir(), "events.jsonl"))
+
+	ff := New(WithClock(clock))
+	ff.SetEventLog(l)
+	for _, id := range ids {
+		if err := ff.Join(id, ""); err != nil {
+			t.Fatalf("Join(%q): %v", id, err)
+		}
+	}
+
+	cfg.Shuffle = false
+	if err := ff.StartWithConfig(ids[0], cfg); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+
+	return ff, clock, l
+}
+
+// playSome makes one of most kinds of move, leaving the game active.
+func playSome(t *testing.T, ff *FireFight, clock *FakeClock) {
+	t.Helper()
+
+	var hit string // Mid-game joins land anywhere in the ring.
+	steps := []func() error{
+		func() error { _, err := ff.Defend("C"); return err },
+		func() error { _, err := ff.ReportHit("C"); return err },
+		func() error { _, err := ff.DisputeHit("D"); return err },
+		func() error { clock.Advance(time.Minute); return nil },
+		func() error { return ff.Pause() },
+		func() error { clock.Advance(time.Hour); return nil },
+		func() error { return ff.Start("A") },
+		func() error { return ff.Join("F", "") },
+		func() error { return ff.AddReferee("A", "R") },
+		func() error { target, err := ff.ReportHit("D"); hit = target.ID; return err },
+		func() error { _, err := ff.DisputeHit(hit); return err },
+		func() error { _, err := ff.Rule("R", hit, true); return err },
+		func() error { return ff.Ban("A", "B") },
+		func() error { _, err := ff.Leave("F"); return err },
+		func() error { clock.Advance(HitCooldown); return nil },
+	}
+
+	for i, step := range steps {
+		if err := step(); err != nil {
+			t.Fatalf("step %d: %v", i, err)
+		}
+	}
+}
+
+func snapshotJSON(t *testing.T, ff *FireFight) string {
+	t.Helper()
+
+	data, err := json.Marshal(ff.snapshot())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(data)
+}
+
+func TestReplayMatchesGame(t *testing.T) {
+	ff, clock, l := newLoggedGame(t, DefaultGameConfig(), "A", "B", "C", "D", "E")
+	playSome(t, ff, clock)
+
+	events, err := l.Events()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := snapshotJSON(t, Replay(events, WithClock(clock))), snapshotJSON(t, ff); got != want {
+		t.Errorf("replayed\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestCatchUpAfterCrash(t *testing.T) {
+	ff, clock, l := newLoggedGame(t, DefaultGameConfig(), "A", "B", "C", "D", "E")
+
+	fs, err := NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := fs.Save("T1_C1", ff); err != nil {
+		t.Fatal(err)
+	}
+
+	// Crash before saving any of these.
+	clock.Advance(time.Second)
+	playSome(t, ff, clock)
+
+	restored, err := fs.Load("T1_C1", WithClock(clock))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := restored.CatchUp(l)
+	if err != nil || n == 0 {
+		t.Fatalf("CatchUp: %d, %v", n, err)
+	}
+
+	if got, want := snapshotJSON(t, restored), snapshotJSON(t, ff); got != want {
+		t.Errorf("caught up\n%s\nwant\n%s", got, want)
+	}
+}
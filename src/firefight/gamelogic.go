@@ -4,8 +4,8 @@ import (
 	crand "crypto/rand"
 	"encoding/binary"
 	"encoding/json"
-	"errors"
 	"fmt"
+	"log/slog"
 	"math/rand"
 	"sort"
 	"sync"
@@ -14,50 +14,83 @@ import (
 
 const (
 	// HitCooldown determins how long a hit player has to dispute.
+	// Default for GameConfig.HitCooldown.
 	HitCooldown = 2 * time.Minute
 
 	// DefensiveCooldown determins how long before a player can register a hit.
+	// Default for GameConfig.DefensiveCooldown.
 	DefensiveCooldown = 5 * time.Minute
 )
 
 type Player struct {
 	ID    string // Slack ID of player
+	Team  string // Empty in free-for-all games.
 	Score int
 
 	DefensiveTimeout time.Time
 
 	HitTimeout time.Time
-	HitBy      *Player // Attacking player. Used to decrement score when disputed.
+	HitBy      string    // ID of attacking player. Used to decrement score when disputed.
+	HitAt      time.Time // When the last hit or claim was made, see HitRef.
 	Hit        bool
+	Disputed   bool // Hit disputed in a refereed game, waiting on a ruling.
+
+	Forfeited bool // Left the game. Only kept for the scoreboard.
+
+	// Confirmed hits only. Attacker with a claim on this player and when
+	// it goes to a ruling if unanswered.
+	PendingBy      string
+	PendingTimeout time.Time
 }
 
-func (p *Player) MarshalJSON() ([]byte, error) {
-	v := struct {
-		ID               string
-		Score            int
-		Hit              bool
-		HitByID          string `json:",omitempty"`
-		HitTimeout       string `json:",omitempty"`
-		DefensiveTimeout string `json:",omitempty"`
-	}{
-		ID:    p.ID,
-		Score: p.Score,
-		Hit:   p.Hit,
-	}
+// playerJSON is how a Player is shown by FireFight.MarshalJSON.
+type playerJSON struct {
+	ID               string
+	Team             string `json:",omitempty"`
+	Score            int
+	Hit              bool
+	HitByID          string `json:",omitempty"`
+	HitTimeout       string `json:",omitempty"`
+	Disputed         bool   `json:",omitempty"`
+	Forfeited        bool   `json:",omitempty"`
+	DefensiveTimeout string `json:",omitempty"`
+	PendingBy        string `json:",omitempty"`
+	PendingTimeout   string `json:",omitempty"`
+}
 
-	if p.HitBy != nil {
-		v.HitByID = p.HitBy.ID
-	}
+// playersJSON shows 'players' as of game time 'now'. Defensive cooldowns
+// that ran out by then are left out.
+func playersJSON(players []Player, now time.Time) []playerJSON {
+	out := make([]playerJSON, 0, len(players))
+	for _, p := range players {
+		v := playerJSON{
+			ID:    p.ID,
+			Team:  p.Team,
+			Score: p.Score,
+			Hit:   p.Hit,
+
+			HitByID:   p.HitBy,
+			Disputed:  p.Disputed,
+			Forfeited: p.Forfeited,
+			PendingBy: p.PendingBy,
+		}
 
-	if !p.HitTimeout.IsZero() {
-		v.HitTimeout = p.HitTimeout.Format(time.RFC1123)
-	}
+		if !p.HitTimeout.IsZero() {
+			v.HitTimeout = p.HitTimeout.Format(time.RFC1123)
+		}
+
+		if !p.DefensiveTimeout.IsZero() && now.Before(p.DefensiveTimeout) {
+			v.DefensiveTimeout = p.DefensiveTimeout.Format(time.RFC1123)
+		}
+
+		if p.PendingBy != "" {
+			v.PendingTimeout = p.PendingTimeout.Format(time.RFC1123)
+		}
 
-	if !p.DefensiveTimeout.IsZero() && time.Now().Before(p.DefensiveTimeout) {
-		v.DefensiveTimeout = p.DefensiveTimeout.Format(time.RFC1123)
+		out = append(out, v)
 	}
 
-	return json.Marshal(v)
+	return out
 }
 
 // PlayerList is a ring buffer of players.
@@ -70,34 +103,43 @@ func (p *Player) MarshalJSON() ([]byte, error) {
 // one other player at all times. After a hit, this also guarantees that the
 // next target will be taken from the hit player.
 //
-// Downside is everything is a table scan so there are methods to help.
+// In team games teammates are skipped over, so the target is the next alive
+// player on another team.
+//
+// Downside is finding targets is a table scan so there are methods to help.
 // Unless the player list contains the entire population of a
-// major metropolitan area, not an issue.
+// major metropolitan area, not an issue. Players are looked up by ID
+// through an index kept by FireFight.
+//
+// Players refer to each other by ID, never by pointer, so the list can be
+// grown, shrunk and reordered freely.
 type PlayerList []Player
 
-// findByID returns the array index of player with the given ID.
-func (pl PlayerList) findByID(id string) int {
+// indexByID maps player IDs to their array index.
+func (pl PlayerList) indexByID() map[string]int {
+	index := make(map[string]int, len(pl))
 	for i, p := range pl {
-		if p.ID == id {
-			return i
-		}
+		index[p.ID] = i
 	}
 
-	return -1 // player not found
+	return index
 }
 
 // findTargetAfter returns the next target array index for a player.
-func (pl PlayerList) findTargetAfter(index int) (tindex int, cooldown bool) {
-	now := time.Now()
+func (pl PlayerList) findTargetAfter(index int, now time.Time) (tindex int, cooldown bool) {
 	for i := 1; i < len(pl); i++ {
 		tindex = (index + i) % len(pl)
 
 		target := pl[tindex]
+		if teammates(pl[index], target) {
+			continue
+		}
+
 		if !target.Hit {
 			return tindex, false
 		}
 
-		if now.Before(target.HitTimeout) {
+		if target.Disputed || now.Before(target.HitTimeout) {
 			return tindex, true // found player who can still dispute
 		}
 	}
@@ -112,7 +154,7 @@ func (pl PlayerList) findHuntedBy(index int) int {
 		hindex := (((index - i) % pCount) + pCount) % pCount
 
 		hunter := pl[hindex]
-		if !hunter.Hit {
+		if !hunter.Hit && !teammates(pl[index], hunter) {
 			return hindex
 		}
 	}
@@ -128,11 +170,81 @@ func rngSeed() int64 {
 
 var rng = rand.New(rand.NewSource(rngSeed()))
 
-// shuffle the playerlist to reassign targets.
-func (pl PlayerList) shuffle() {
-	rng.Shuffle(len(pl), func(i, j int) {
-		pl[i], pl[j] = pl[j], pl[i]
+// ids returns the player IDs in ring order.
+func (pl PlayerList) ids() []string {
+	ids := make([]string, len(pl))
+	for i, p := range pl {
+		ids[i] = p.ID
+	}
+
+	return ids
+}
+
+// shuffledIDs returns the player IDs in a new random ring order.
+func (pl PlayerList) shuffledIDs() []string {
+	ids := pl.ids()
+	rng.Shuffle(len(ids), func(i, j int) {
+		ids[i], ids[j] = ids[j], ids[i]
 	})
+
+	return ids
+}
+
+// insertRandom returns 'ids' with 'id' added at a random spot.
+func insertRandom(ids []string, id string) []string {
+	at := rng.Intn(len(ids) + 1)
+	ids = append(ids, "")
+	copy(ids[at+1:], ids[at:])
+	ids[at] = id
+
+	return ids
+}
+
+// reorder arranges the playerlist to match 'ids' to reassign targets.
+// Players missing from 'ids' are dropped.
+func (pl PlayerList) reorder(ids []string) PlayerList {
+	index := pl.indexByID()
+
+	ordered := make(PlayerList, 0, len(ids))
+	for _, id := range ids {
+		if i, ok := index[id]; ok {
+			ordered = append(ordered, pl[i])
+		}
+	}
+
+	return ordered
+}
+
+// rematch brings everyone back for a new round with a clean slate.
+// Teams stay the same.
+func (pl PlayerList) rematch() {
+	for i := range pl {
+		pl[i] = Player{ID: pl[i].ID, Team: pl[i].Team}
+	}
+}
+
+// remove drops the player at 'index'.
+// Their hunter inherits their target, as with any hit.
+func (pl PlayerList) remove(index int) PlayerList {
+	id := pl[index].ID
+
+	remaining := make(PlayerList, 0, len(pl)-1)
+	remaining = append(remaining, pl[:index]...)
+	remaining = append(remaining, pl[index+1:]...)
+
+	// Hits and claims made by the removed player go with them.
+	for i := range remaining {
+		if remaining[i].HitBy == id {
+			remaining[i].HitBy = ""
+		}
+
+		if remaining[i].PendingBy == id {
+			remaining[i].PendingBy = ""
+			remaining[i].PendingTimeout = time.Time{}
+		}
+	}
+
+	return remaining
 }
 
 type GameState int
@@ -145,6 +257,8 @@ func (gs GameState) String() string {
 		return "active"
 	case StatePaused:
 		return "paused"
+	case StateFinished:
+		return "finished"
 	default:
 		return fmt.Sprintf("GameState(%d)", int(gs))
 	}
@@ -154,63 +268,199 @@ const (
 	StateIdle GameState = iota
 	StateActive
 	StatePaused
+	StateFinished // Winner decided. Start again for a rematch with the same players.
 )
 
 type FireFight struct {
 	Created time.Time
+	Updated time.Time // Time of the last change.
 	mu      sync.RWMutex
 	State   GameState
 	// state uint32
 
 	Players PlayerList
+	index   map[string]int // Player ID to index in Players.
+
+	Owner  string   // Started the game from the lobby.
+	Admins []string // Named by the owner to help run the game.
+
+	// Banned can't join. Unlike the rest, bans outlast the game.
+	Banned []string
+
+	// Forfeited holds players who left mid-game, so their points still count.
+	Forfeited []Player
+
+	// Referees rule on disputes. Without any, disputes revive right away.
+	Referees []string
+
+	// PausedAt is when the game was paused. Cooldowns stand still until
+	// it resumes.
+	PausedAt time.Time
+
+	Winner      string // ID of the last player standing once finished.
+	WinningTeam string // Last team standing once a team game is finished.
+
+	Config   GameConfig
+	EndsAt   time.Time  // Set for games with a Duration.
+	defaults GameConfig // Rules of new games started without any.
+
+	events EventLog // Optional. Every change is appended here first.
+
+	notifier *Notifier // Optional. Announces expired cooldowns.
+	timer    Timer     // Fires at the next cooldown to expire.
+	checked  time.Time // Cooldowns up to here have been announced.
+
+	store   Store  // Optional. Saves changes made by the timer.
+	storeID string // Key of the game in store.
+	changed bool   // Something was recorded since the timer last ran.
+
+	log *slog.Logger // Optional. Carries the game's key, see SetLogger.
+
+	clock Clock
+}
+
+func New(opts ...Option) *FireFight {
+	ff := &FireFight{Config: DefaultGameConfig(), defaults: DefaultGameConfig(), clock: RealClock{}}
+	for _, opt := range opts {
+		opt(ff)
+	}
+
+	ff.Created = ff.clock.Now()
+	ff.Updated = ff.Created
+	ff.checked = ff.Created
+
+	return ff
 }
 
-func New() *FireFight {
-	return &FireFight{Created: time.Now()}
+// gameTime is 'now' as far as cooldowns are concerned.
+// Time does not pass while the game is paused.
+//
+// ff.mu must be held.
+func (ff *FireFight) gameTime(now time.Time) time.Time {
+	if ff.State == StatePaused && now.After(ff.PausedAt) {
+		return ff.PausedAt
+	}
+
+	return now
 }
 
-func (ff *FireFight) MarshalJSON() ([]byte, error) {
-	now := time.Now()
+// find returns the array index of player with the given ID.
+//
+// ff.mu must be held.
+func (ff *FireFight) find(id string) int {
+	if i, ok := ff.index[id]; ok && i < len(ff.Players) && ff.Players[i].ID == id {
+		return i
+	}
+
+	return -1 // player not found
+}
+
+// reindex rebuilds the index used by find. Needed whenever players are
+// added, removed or reordered.
+//
+// ff.mu must be held.
+func (ff *FireFight) reindex() {
+	ff.index = ff.Players.indexByID()
+}
+
+// revive takes back the hit on player at 'index' and the point it earned.
+//
+// ff.mu must be held.
+func (ff *FireFight) revive(index int) {
+	p := &ff.Players[index]
+	p.Hit = false
+	p.Disputed = false
+	if p.HitBy == "" {
+		// Did you shoot yourself? Whatever.
+		return
+	}
 
+	if aindex := ff.find(p.HitBy); aindex != -1 {
+		ff.Players[aindex].Score--
+	}
+	p.HitBy = ""
+}
+
+// Stats counts players by status.
+type Stats struct {
+	Alive, Dead, Disputable, Defended, Total int
+}
+
+// Stats counts the players in the ring by status.
+func (ff *FireFight) Stats() Stats {
 	ff.mu.RLock()
 	defer ff.mu.RUnlock()
 
-	var aliveCount, deadCount, disputableCount, defendedCount int
+	return ff.stats(ff.gameTime(ff.clock.Now()))
+}
+
+// ff.mu must be held.
+func (ff *FireFight) stats(now time.Time) Stats {
+	s := Stats{Total: len(ff.Players)}
 	for _, p := range ff.Players {
 		if p.Hit {
-			deadCount++
+			s.Dead++
 			if now.Before(p.HitTimeout) {
-				disputableCount++
+				s.Disputable++
 			}
 		} else {
-			aliveCount++
+			s.Alive++
 			if now.Before(p.DefensiveTimeout) {
-				defendedCount++
+				s.Defended++
 			}
 		}
 	}
 
-	type Stats struct {
-		Alive, Dead, Disputable, Defended, Total int
-	}
+	return s
+}
+
+func (ff *FireFight) MarshalJSON() ([]byte, error) {
+	ff.mu.RLock()
+	defer ff.mu.RUnlock()
+
+	now := ff.gameTime(ff.clock.Now())
 
 	v := struct {
 		Created     string
+		Updated     string
 		State       string
+		Rules       string
+		EndsAt      string      `json:",omitempty"`
+		Winner      string      `json:",omitempty"`
+		WinningTeam string      `json:",omitempty"`
+		Teams       []TeamScore `json:",omitempty"`
+		Owner       string      `json:",omitempty"`
+		Admins      []string    `json:",omitempty"`
+		Banned      []string    `json:",omitempty"`
+		Referees    []string    `json:",omitempty"`
+		Disputes    []Dispute   `json:",omitempty"`
 		PlayerStats Stats
-		Players     PlayerList
+		Players     []playerJSON
+		Forfeited   []playerJSON `json:",omitempty"`
 	}{
-		Created: ff.Created.Format(time.RFC1123),
-		State:   ff.State.String(),
-		PlayerStats: Stats{
-			Alive:      aliveCount,
-			Dead:       deadCount,
-			Disputable: disputableCount,
-			Defended:   defendedCount,
-			Total:      len(ff.Players),
-		},
-		Players: ff.Players,
+		Created:     ff.Created.Format(time.RFC1123),
+		Updated:     ff.Updated.Format(time.RFC1123),
+		State:       ff.State.String(),
+		Rules:       ff.Config.String(),
+		Winner:      ff.Winner,
+		WinningTeam: ff.WinningTeam,
+		Teams:       teamScores(ff.standings(), ff.WinningTeam),
+		Owner:       ff.Owner,
+		Admins:      ff.Admins,
+		Banned:      ff.Banned,
+		Referees:    ff.Referees,
+		Disputes:    ff.disputes(now),
+		PlayerStats: ff.stats(now),
+		Players:     playersJSON(ff.Players, now),
+	}
+	if !ff.EndsAt.IsZero() {
+		v.EndsAt = ff.EndsAt.Format(time.RFC1123)
+	}
+
+	if len(ff.Forfeited) > 0 {
+		v.Forfeited = playersJSON(ff.Forfeited, now)
 	}
+
 	return json.Marshal(v)
 }
 
@@ -219,263 +469,739 @@ func (ff *FireFight) MarshalJSON() ([]byte, error) {
 // 	return GameState(atomic.LoadUint32(&ff.state))
 // }
 
+// SetEventLog attaches the log every future change is recorded to.
+func (ff *FireFight) SetEventLog(l EventLog) {
+	ff.mu.Lock()
+	defer ff.mu.Unlock()
+
+	ff.events = l
+}
+
+// record appends 'e' to the event log and then applies it.
+// Nothing changes if the event could not be written.
+//
+// ff.mu must be held.
+func (ff *FireFight) record(e Event) error {
+	if ff.events != nil {
+		if err := ff.events.Append(e); err != nil {
+			return err
+		}
+	}
+
+	ff.apply(e)
+	ff.changed = true
+	EventsTotal.Inc(Labels("type", string(e.Type)))
+
+	if text := ff.finishIfWon(e.Time); text != "" {
+		ff.announce(text)
+	}
+
+	ff.schedule()
+
+	return nil
+}
+
+// winner returns the index of the last player standing once every hit on
+// the others is final, or -1 while the fight is still on. A player left
+// alone in the ring after the rest left or were kicked wins too.
+// In team games it's any of the players left standing, all on one team.
+//
+// ff.mu must be held.
+func (ff *FireFight) winner(now time.Time) int {
+	if ff.State != StateActive {
+		return -1
+	}
+
+	if ff.Config.Respawn > 0 {
+		return -1 // nobody stays down, only the clock ends it
+	}
+
+	windex := -1
+	for i, p := range ff.Players {
+		switch {
+		case p.PendingBy != "", p.Disputed:
+			return -1 // hit not settled yet
+		case p.Hit && now.Before(p.HitTimeout):
+			return -1 // could still be disputed
+		case p.Hit:
+		case windex == -1:
+			windex = i
+		case !teammates(p, ff.Players[windex]):
+			return -1 // more than one standing
+		}
+	}
+
+	if windex == -1 && len(ff.Players) == 1 {
+		return 0 // down, but whoever hit them left
+	}
+
+	return windex
+}
+
+// finishIfWon ends the game once there is a winner and returns the final
+// announcement. Returns "" if the game goes on.
+//
+// ff.mu must be held.
+func (ff *FireFight) finishIfWon(now time.Time) string {
+	if ff.timeUp(now) {
+		return ff.finishOnPoints(now)
+	}
+
+	if ff.State == StateActive && len(ff.Players) == 0 {
+		if err := ff.record(Event{Time: now, Type: EventFinish}); err != nil {
+			return ""
+		}
+
+		return "[FireFight Over] Everyone left. Nobody wins."
+	}
+
+	windex := ff.winner(now)
+	if windex == -1 {
+		return ""
+	}
+
+	e := Event{Time: now, Type: EventFinish, Player: ff.Players[windex].ID}
+	if team := ff.Players[windex].Team; team != "" {
+		e = Event{Time: now, Type: EventFinish, Team: team}
+	}
+
+	if err := ff.record(e); err != nil {
+		return "" // try again on the next change
+	}
+
+	if e.Team != "" {
+		return fmt.Sprintf("[FireFight Over] Team %s is the last team standing!\n%s%s", e.Team,
+			teamScoreboardText(teamScores(ff.standings(), e.Team)), scoreboardText(ff.scoreboard()))
+	}
+
+	return fmt.Sprintf("[FireFight Over] <@%s> is the last one standing!\n%s",
+		e.Player, scoreboardText(ff.scoreboard()))
+}
+
 // Start initiates new game or unpauses.
-func (ff *FireFight) Start() error {
+// Player 'by' owns a game started from the lobby.
+//
+// New games are played by default rules, rematches keep the last game's.
+// See SetDefaults.
+func (ff *FireFight) Start(by string) error {
 	ff.mu.Lock()
 	defer ff.mu.Unlock()
 
+	cfg := ff.Config
+	if ff.State == StateIdle {
+		cfg = ff.defaults
+	}
+
+	return ff.start(by, &cfg)
+}
+
+// StartWithConfig initiates a new game played by 'cfg'.
+func (ff *FireFight) StartWithConfig(by string, cfg GameConfig) error {
+	ff.mu.Lock()
+	defer ff.mu.Unlock()
+
+	if ff.State == StatePaused {
+		return ErrRulesLocked
+	}
+
+	return ff.start(by, &cfg)
+}
+
+// ff.mu must be held.
+func (ff *FireFight) start(by string, cfg *GameConfig) error {
+	e := Event{Time: ff.clock.Now(), Type: EventStart, Player: by}
+
+	if ff.isBanned(by) {
+		return ErrBanned
+	}
+
 	switch ff.State {
 	case StateActive:
-		return errors.New("Game still in progress.")
-	case StateIdle:
-		ff.Players.shuffle()
-		ff.State = StateActive
-	case StatePaused:
-		ff.State = StateActive
+		return ErrGameInProgress
+	case StateIdle, StateFinished:
+		if len(ff.Players) < 2 {
+			return ErrTooFewPlayers
+		}
+
+		if err := ff.Players.checkTeams(); err != nil {
+			return err
+		}
+
+		e.Config = cfg
+		e.Order = ff.Players.ids()
+		if cfg.Shuffle {
+			e.Order = ff.Players.shuffledIDs()
+		}
 	}
 
-	return nil
+	return ff.record(e)
+}
+
+// SetDefaults changes the rules new games are played by when started
+// without any.
+func (ff *FireFight) SetDefaults(cfg GameConfig) {
+	ff.mu.Lock()
+	defer ff.mu.Unlock()
+
+	ff.defaults = cfg
+}
+
+// Defaults returns the rules new games are played by when started without
+// any. Rules given on start are read on top of them.
+func (ff *FireFight) Defaults() GameConfig {
+	ff.mu.RLock()
+	defer ff.mu.RUnlock()
+
+	return ff.defaults
+}
+
+// CurrentState is a safe way to poll the game state.
+func (ff *FireFight) CurrentState() GameState {
+	ff.mu.RLock()
+	defer ff.mu.RUnlock()
+
+	return ff.State
+}
+
+// Dormant reports if the game is an empty lobby or finished and hasn't
+// changed since 'since'. Dormant games have nothing scheduled, so they can
+// be dropped from memory and loaded again when needed.
+func (ff *FireFight) Dormant(since time.Time) bool {
+	ff.mu.RLock()
+	defer ff.mu.RUnlock()
+
+	if ff.Updated.After(since) {
+		return false
+	}
+
+	return ff.State == StateFinished || ff.State == StateIdle && len(ff.Players) == 0
+}
+
+// Rules returns the config the game is played by.
+func (ff *FireFight) Rules() GameConfig {
+	ff.mu.RLock()
+	defer ff.mu.RUnlock()
+
+	return ff.Config
 }
 
 // Pause game in progress.
 func (ff *FireFight) Pause() error {
+	return ff.pause("")
+}
+
+// ForcePause pauses a game in progress on behalf of 'admin'.
+func (ff *FireFight) ForcePause(admin string) error {
+	return ff.pause(admin)
+}
+
+func (ff *FireFight) pause(admin string) error {
 	ff.mu.Lock()
 	defer ff.mu.Unlock()
 
 	switch ff.State {
-	case StateActive:
-		ff.State = StatePaused
 	case StateIdle:
-		return errors.New("No active game.")
+		return ErrNoActiveGame
 	case StatePaused:
-		return errors.New("Game already paused.")
+		return ErrGamePaused
+	case StateFinished:
+		return ErrGameOver
 	}
 
-	return nil
+	return ff.record(Event{Time: ff.clock.Now(), Type: EventPause, Admin: admin})
 }
 
-// Ends paused game and returns final scoreboard.
-func (ff *FireFight) End() ([]Player, error) {
-	scoreboard := ff.Scoreboard()
-
+// Ends paused or finished game and returns final scoreboard, and team
+// standings in a team game. Players on 0 points count for their team too.
+func (ff *FireFight) End() ([]Player, []TeamScore, error) {
 	ff.mu.Lock()
 	defer ff.mu.Unlock()
 
 	switch ff.State {
 	case StateIdle:
-		return nil, errors.New("No active game.")
+		return nil, nil, ErrNoActiveGame
 	case StateActive:
-		return nil, errors.New("Cannot end active game. /ffpause first.")
-	case StatePaused:
-		ff.Players = ff.Players[0:0]
-		ff.State = StateIdle
+		return nil, nil, ErrGameInProgress
+	}
+
+	return ff.end("")
+}
+
+// ForceEnd ends a game whether or not it is paused and returns what End
+// does. Meant for admins, players use End.
+func (ff *FireFight) ForceEnd(admin string) ([]Player, []TeamScore, error) {
+	ff.mu.Lock()
+	defer ff.mu.Unlock()
+
+	if ff.State == StateIdle {
+		return nil, nil, ErrNoActiveGame
 	}
 
-	return scoreboard, nil
+	return ff.end(admin)
+}
+
+// end records the end of the game, taking the final standings first as
+// ending clears the roster.
+//
+// ff.mu must be held.
+func (ff *FireFight) end(admin string) ([]Player, []TeamScore, error) {
+	scoreboard := ff.scoreboard()
+	teams := teamScores(ff.standings(), ff.WinningTeam)
+
+	if err := ff.record(Event{Time: ff.clock.Now(), Type: EventEnd, Admin: admin}); err != nil {
+		return nil, nil, err
+	}
+
+	return scoreboard, teams, nil
+}
+
+// RemovePlayer takes player with 'id' out of the game at any stage.
+// Hits they made can no longer be taken back from them.
+func (ff *FireFight) RemovePlayer(admin, id string) error {
+	ff.mu.Lock()
+	defer ff.mu.Unlock()
+
+	if ff.find(id) == -1 {
+		return ErrNotPlayer
+	}
+
+	return ff.record(Event{Time: ff.clock.Now(), Type: EventRemove, Target: id, Admin: admin})
+}
+
+// SetScore overrides the score of player with 'id'.
+func (ff *FireFight) SetScore(admin, id string, score int) error {
+	ff.mu.Lock()
+	defer ff.mu.Unlock()
+
+	if ff.find(id) == -1 {
+		return ErrNotPlayer
+	}
+
+	return ff.record(Event{Time: ff.clock.Now(), Type: EventScore, Target: id, Score: score, Admin: admin})
 }
 
 // Reset forcefully resets game object.
-func (ff *FireFight) Reset(id string) {
+func (ff *FireFight) Reset(admin string) error {
 	ff.mu.Lock()
 	defer ff.mu.Unlock()
 
-	ff.Players = ff.Players[0:0]
-	ff.State = StateIdle
+	return ff.record(Event{Time: ff.clock.Now(), Type: EventReset, Admin: admin})
 }
 
-// Join pregame loby.
-func (ff *FireFight) Join(id string) error {
+// Join pregame loby. Give a 'team' to play a team game, everyone must then.
+// Players joining a game in progress are dropped into the ring at a random
+// spot.
+func (ff *FireFight) Join(id, team string) error {
+	team, err := normalizeTeam(team)
+	if err != nil {
+		return err
+	}
+
 	ff.mu.Lock()
 	defer ff.mu.Unlock()
 
-	if ff.State != StateIdle {
-		return errors.New("Game already in progress. Take shelter.")
+	if ff.isBanned(id) {
+		return ErrBanned
 	}
 
-	if ff.Players.findByID(id) != -1 {
-		return errors.New("Already joined.")
+	if ff.find(id) != -1 {
+		return ErrAlreadyJoined
 	}
 
-	ff.Players = append(ff.Players, Player{ID: id})
+	if ff.hasForfeited(id) {
+		return ErrForfeited
+	}
 
-	return nil
+	if len(ff.Players) > 0 {
+		switch teamMode := ff.Players.teamMode(); {
+		case teamMode && team == "":
+			return ErrTeamRequired
+		case !teamMode && team != "":
+			return ErrNoTeams
+		}
+	}
+
+	e := Event{Time: ff.clock.Now(), Type: EventJoin, Player: id, Team: team}
+	if ff.State == StateActive || ff.State == StatePaused {
+		e.Order = insertRandom(ff.Players.ids(), id)
+	}
+
+	return ff.record(e)
+}
+
+// Leave takes player with 'id' out of the game. Their hunter inherits their
+// target. Points scored in a game that already started are kept as
+// forfeited.
+func (ff *FireFight) Leave(id string) (*Player, error) {
+	ff.mu.Lock()
+	defer ff.mu.Unlock()
+
+	index := ff.find(id)
+	if index == -1 {
+		return nil, ErrNotPlayer
+	}
+
+	p := ff.Players[index]
+	p.Forfeited = ff.State != StateIdle
+
+	if err := ff.record(Event{Time: ff.clock.Now(), Type: EventLeave, Player: id}); err != nil {
+		return nil, err
+	}
+
+	return &p, nil
+}
+
+// hasForfeited reports if 'id' left the current game.
+//
+// ff.mu must be held.
+func (ff *FireFight) hasForfeited(id string) bool {
+	for _, p := range ff.Forfeited {
+		if p.ID == id {
+			return true
+		}
+	}
+
+	return false
+}
+
+// standings is every player who scored in this game, forfeited included.
+//
+// ff.mu must be held.
+func (ff *FireFight) standings() []Player {
+	players := make([]Player, 0, len(ff.Players)+len(ff.Forfeited))
+	players = append(players, ff.Players...)
+
+	return append(players, ff.Forfeited...)
 }
 
 // GetTarget returns the next available target of player with 'id'.
-func (ff *FireFight) GetTarget(id string) (*Player, error) {
+// Players are returned by value, copied while the game is locked.
+func (ff *FireFight) GetTarget(id string) (Player, error) {
 	ff.mu.RLock()
 	defer ff.mu.RUnlock()
 
 	switch ff.State {
 	case StateIdle:
-		return nil, errors.New("No active game.")
+		return Player{}, ErrNoActiveGame
+	case StateFinished:
+		return Player{}, ErrGameOver
 	}
 
-	index := ff.Players.findByID(id)
+	index := ff.find(id)
 	if index == -1 {
-		return nil, errors.New("You can't win if you don't play.")
+		return Player{}, ErrNotPlayer
 	}
 
-	if ff.Players[index].Hit {
-		return nil, errors.New("No targets for the fallen.")
+	now := ff.gameTime(ff.clock.Now())
+
+	if p := ff.Players[index]; p.Hit {
+		if t, ok := ff.respawnAt(p); ok {
+			return Player{}, &ErrRespawning{Remaining: t.Sub(now).Truncate(1 * time.Second)}
+		}
+
+		return Player{}, ErrDead
 	}
 
-	tindex, cooldown := ff.Players.findTargetAfter(index)
+	tindex, cooldown := ff.Players.findTargetAfter(index, now)
 
 	if tindex == -1 {
-		return nil, errors.New("No targets.")
+		return Player{}, ErrNoTarget
 	}
 
 	target := &ff.Players[tindex]
 
+	if target.Disputed {
+		return Player{}, ErrTargetDisputed
+	}
+
 	if cooldown {
-		d := time.Until(target.HitTimeout).Truncate(1 * time.Second)
-		return nil, fmt.Errorf("Slow down there, hotshot. [%s]", d)
+		return Player{}, &ErrOnCooldown{Remaining: target.HitTimeout.Sub(now).Truncate(1 * time.Second)}
 	}
 
-	return &ff.Players[tindex], nil
+	return ff.Players[tindex], nil
 }
 
 // ReportHit marks next target of play with 'id' (attacker) as hit.
-func (ff *FireFight) ReportHit(id string) (*Player, error) {
-	now := time.Now()
-
+func (ff *FireFight) ReportHit(id string) (Player, error) {
 	ff.mu.Lock()
 	defer ff.mu.Unlock()
 
+	now := ff.clock.Now()
+
 	switch ff.State {
 	case StateIdle:
-		return nil, errors.New("Ceasefire! No active game.")
+		return Player{}, ErrNoActiveGame
 	case StatePaused:
-		return nil, errors.New("Ceasefire! Game is paused.")
+		return Player{}, ErrGamePaused
+	case StateFinished:
+		return Player{}, ErrGameOver
 	}
 
-	index := ff.Players.findByID(id)
+	index := ff.find(id)
 	if index == -1 {
-		return nil, errors.New("You can't win if you don't play.")
+		return Player{}, ErrNotPlayer
 	}
 
 	player := &ff.Players[index]
 
 	if player.Hit {
-		return nil, errors.New("Martyrdom isn't a perk. You're dead.")
+		return Player{}, ErrDead
 	}
 
 	if now.Before(player.DefensiveTimeout) {
-		d := player.DefensiveTimeout.Sub(now).Truncate(1 * time.Second)
-		return nil, fmt.Errorf("In defensive cooldown. Can't attack. [%s]", d)
+		return Player{}, &ErrOnCooldown{
+			Remaining: player.DefensiveTimeout.Sub(now).Truncate(1 * time.Second),
+			Defensive: true,
+		}
 	}
 
-	tindex, cooldown := ff.Players.findTargetAfter(index)
+	tindex, cooldown := ff.Players.findTargetAfter(index, now)
 
 	if tindex == -1 {
-		return nil, errors.New("No target to hit.")
+		if text := ff.finishIfWon(now); text != "" {
+			ff.announce(text)
+			return Player{}, ErrLastStanding
+		}
+
+		return Player{}, ErrNoTarget
 	}
 
 	target := &ff.Players[tindex]
 
+	if target.Disputed {
+		return Player{}, ErrTargetDisputed
+	}
+
 	if cooldown {
-		d := target.HitTimeout.Sub(now).Truncate(1 * time.Second)
-		return nil, fmt.Errorf("Slow down there, hotshot. [%s]", d)
+		return Player{}, &ErrOnCooldown{Remaining: target.HitTimeout.Sub(now).Truncate(1 * time.Second)}
 	}
 
-	target.HitTimeout = now.Add(HitCooldown)
-	target.HitBy = &ff.Players[index]
-	target.Hit = true
+	if ff.Config.ConfirmWindow > 0 {
+		return ff.claimHit(now, index, target)
+	}
 
-	player.Score++
+	e := Event{Time: now, Type: EventHit, Player: id, Target: target.ID}
+	if err := ff.record(e); err != nil {
+		return Player{}, err
+	}
 
-	return target, nil
+	return *target, nil
 }
 
 // Defend player with 'id' with a hunter cooldown.
-func (ff *FireFight) Defend(id string) (*Player, error) {
+func (ff *FireFight) Defend(id string) (Player, error) {
 	ff.mu.Lock()
 	defer ff.mu.Unlock()
 
 	switch ff.State {
 	case StateIdle:
-		return nil, errors.New("No active game.")
+		return Player{}, ErrNoActiveGame
 	case StatePaused:
-		return nil, errors.New("Game is paused.")
+		return Player{}, ErrGamePaused
+	case StateFinished:
+		return Player{}, ErrGameOver
 	}
 
-	index := ff.Players.findByID(id)
+	index := ff.find(id)
 	if index == -1 {
-		return nil, errors.New("Not in game.")
+		return Player{}, ErrNotPlayer
 	}
 
 	if ff.Players[index].Hit {
-		return nil, errors.New("You've already been hit. Can't defend.")
+		return Player{}, ErrAlreadyHit
 	}
 
 	hindex := ff.Players.findHuntedBy(index)
 
 	if hindex == -1 {
-		return nil, errors.New("Not being hunted.")
+		return Player{}, ErrNotHunted
 	}
 
 	hunter := &ff.Players[hindex]
-	hunter.DefensiveTimeout = time.Now().Add(DefensiveCooldown)
 
-	return hunter, nil
+	e := Event{Time: ff.clock.Now(), Type: EventDefend, Player: id, Target: hunter.ID}
+	if err := ff.record(e); err != nil {
+		return Player{}, err
+	}
+
+	return *hunter, nil
+}
+
+// HitRef names one hit or claim on a player, so an answer to it can't land
+// on a later one, e.g. from the buttons under an old hit.
+type HitRef struct {
+	Target   string
+	Attacker string
+	At       time.Time
+}
+
+// matches reports if 'p' is still down to, or claimed by, the hit 'ref'.
+func (ref HitRef) matches(p Player) bool {
+	if p.ID != ref.Target || !p.HitAt.Equal(ref.At) {
+		return false
+	}
+
+	return p.PendingBy == ref.Attacker || p.Hit && p.HitBy == ref.Attacker
 }
 
 // DisputeHit revives player if within the cooldown period.
-func (ff *FireFight) DisputeHit(id string) error {
+//
+// Disputing any hit once the game has referees sends it for a ruling
+// instead, 'revived' is false then. So does disputing a pending claim,
+// which is simply dropped without referees.
+func (ff *FireFight) DisputeHit(id string) (revived bool, err error) {
+	return ff.disputeHit(id, nil)
+}
+
+// DisputeHitRef is DisputeHit of the hit 'ref' only.
+func (ff *FireFight) DisputeHitRef(ref HitRef) (revived bool, err error) {
+	return ff.disputeHit(ref.Target, &ref)
+}
+
+func (ff *FireFight) disputeHit(id string, ref *HitRef) (revived bool, err error) {
 	ff.mu.Lock()
 	defer ff.mu.Unlock()
 
 	switch ff.State {
 	case StateIdle:
-		return errors.New("No active game.")
+		return false, ErrNoActiveGame
 	case StatePaused:
 		// I guess reviving here is ok?
+	case StateFinished:
+		return false, ErrGameOver
 	}
 
-	index := ff.Players.findByID(id)
+	index := ff.find(id)
 	if index == -1 {
-		return errors.New("You can't lose if you don't play.")
+		return false, ErrNotPlayer
 	}
 
 	p := &ff.Players[index]
+	now := ff.clock.Now()
+
+	if ref != nil && !ref.matches(*p) {
+		return false, ErrStaleHit
+	}
+
+	if p.PendingBy != "" {
+		switch {
+		case len(ff.Referees) == 0 && p.awaitingRuling(ff.gameTime(now)):
+			return false, ErrDisputeExpired
+		case p.awaitingRuling(ff.gameTime(now)):
+			return false, ErrAwaitingRuling
+		case len(ff.Referees) == 0:
+			return true, ff.record(Event{Time: now, Type: EventOverturn, Player: id})
+		}
+
+		return false, ff.record(Event{Time: now, Type: EventReject, Player: id})
+	}
 
 	if !p.Hit {
 		// tis but a scratch
-		return errors.New("It was only a scratch. You're still in this fight!")
+		return false, ErrNotHit
 	}
 
-	if time.Now().After(p.HitTimeout) {
-		return errors.New("This ones been sitting awhile and necromancy isn't my specialty.")
+	if p.Disputed {
+		return false, ErrAwaitingRuling
 	}
 
-	p.Hit = false
-	if p.HitBy == nil {
-		// Did you shoot yourself? Whatever.
-		return nil
+	if ff.gameTime(now).After(p.HitTimeout) {
+		return false, ErrDisputeExpired
 	}
 
-	p.HitBy.Score--
-	p.HitBy = nil
+	if len(ff.Referees) > 0 {
+		return false, ff.record(Event{Time: now, Type: EventAppeal, Player: id})
+	}
 
-	return nil
+	return true, ff.record(Event{Time: now, Type: EventDispute, Player: id})
+}
+
+// ConfirmHit lets the hit player with 'id' accept the hit before the
+// cooldown runs out, freeing their hunter to move on. Also accepts a
+// pending claim or withdraws a dispute.
+func (ff *FireFight) ConfirmHit(id string) error {
+	return ff.confirmHit(id, nil)
+}
+
+// ConfirmHitRef is ConfirmHit of the hit 'ref' only.
+func (ff *FireFight) ConfirmHitRef(ref HitRef) error {
+	return ff.confirmHit(ref.Target, &ref)
+}
+
+func (ff *FireFight) confirmHit(id string, ref *HitRef) error {
+	ff.mu.Lock()
+	defer ff.mu.Unlock()
+
+	switch ff.State {
+	case StateIdle:
+		return ErrNoActiveGame
+	case StateFinished:
+		return ErrGameOver
+	}
+
+	index := ff.find(id)
+	if index == -1 {
+		return ErrNotPlayer
+	}
+
+	p := &ff.Players[index]
+	now := ff.clock.Now()
+
+	if ref != nil && !ref.matches(*p) {
+		return ErrStaleHit
+	}
+
+	if p.PendingBy != "" {
+		if len(ff.Referees) > 0 && p.awaitingRuling(ff.gameTime(now)) {
+			return ErrAwaitingRuling
+		}
+
+		return ff.record(Event{Time: now, Type: EventAccept, Player: id})
+	}
+
+	if !p.Hit {
+		return ErrNotHit
+	}
+
+	if p.Disputed {
+		return ff.record(Event{Time: now, Type: EventAccept, Player: id})
+	}
+
+	if ff.gameTime(now).After(p.HitTimeout) {
+		return ErrHitFinal
+	}
+
+	return ff.record(Event{Time: now, Type: EventConfirm, Player: id})
 }
 
 // Scoreboard returns a sorted list of all scoring players.
+// The winner of a finished game always comes first.
 func (ff *FireFight) Scoreboard() []Player {
 	ff.mu.RLock()
 	defer ff.mu.RUnlock()
 
+	return ff.scoreboard()
+}
+
+// ff.mu must be held.
+func (ff *FireFight) scoreboard() []Player {
 	scoringPlayers := make([]Player, 0, len(ff.Players))
-	for _, p := range ff.Players {
-		if p.Score == 0 {
+	for _, p := range ff.standings() {
+		if p.Score == 0 && p.ID != ff.Winner {
 			continue
 		}
 
 		scoringPlayers = append(scoringPlayers, p)
 	}
 
-	sort.Slice(scoringPlayers, func(i, j int) bool {
+	sort.SliceStable(scoringPlayers, func(i, j int) bool {
+		if scoringPlayers[i].ID == ff.Winner {
+			return scoringPlayers[j].ID != ff.Winner
+		}
+		if scoringPlayers[j].ID == ff.Winner {
+			return false
+		}
+
 		return scoringPlayers[j].Score < scoringPlayers[i].Score
 	})
 
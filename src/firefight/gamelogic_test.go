@@ -0,0 +1,358 @@
+package firefight
+
+import (
+	"testing"
+	"time"
+)
+
+var epoch = time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+
+// newTestGame starts an unshuffled game between 'ids' on a fake clock, so
+// the ring is in join order.
+func newTestGame(t *testing.T, ids ...string) (*FireFight, *FakeClock) {
+	t.Helper()
+
+	return newTestGameWith(t, DefaultGameConfig(), ids...)
+}
+
+// newTestGameWith is newTestGame played by 'cfg'.
+func newTestGameWith(t *testing.T, cfg GameConfig, ids ...string) (*FireFight, *FakeClock) {
+	t.Helper()
+
+	clock := NewFakeClock(epoch)
+	ff := New(WithClock(clock))
+	for _, id := range ids {
+		if err := ff.Join(id, ""); err != nil {
+			t.Fatalf("Join(%q): %v", id, err)
+		}
+	}
+
+	cfg.Shuffle = false
+	if err := ff.StartWithConfig(ids[0], cfg); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+
+	return ff, clock
+}
+
+func TestFindTargetAfter(t *testing.T) {
+	later := epoch.Add(time.Minute)
+
+	tests := []struct {
+		name         string
+		players      PlayerList
+		index        int
+		wantIndex    int
+		wantCooldown bool
+	}{
+		{
+			name:      "next player",
+			players:   PlayerList{{ID: "A"}, {ID: "B"}, {ID: "C"}},
+			index:     0,
+			wantIndex: 1,
+		},
+		{
+			name:      "wraps around",
+			players:   PlayerList{{ID: "A"}, {ID: "B"}, {ID: "C"}},
+			index:     2,
+			wantIndex: 0,
+		},
+		{
+			name:      "skips the dead",
+			players:   PlayerList{{ID: "A"}, {ID: "B", Hit: true}, {ID: "C"}},
+			index:     0,
+			wantIndex: 2,
+		},
+		{
+			name:         "stops at a hit still in cooldown",
+			players:      PlayerList{{ID: "A"}, {ID: "B", Hit: true, HitTimeout: later}, {ID: "C"}},
+			index:        0,
+			wantIndex:    1,
+			wantCooldown: true,
+		},
+		{
+			name:         "stops at a disputed hit",
+			players:      PlayerList{{ID: "A"}, {ID: "B", Hit: true, Disputed: true}, {ID: "C"}},
+			index:        0,
+			wantIndex:    1,
+			wantCooldown: true,
+		},
+		{
+			name:      "skips teammates",
+			players:   PlayerList{{ID: "A", Team: "red"}, {ID: "B", Team: "red"}, {ID: "C", Team: "blue"}},
+			index:     0,
+			wantIndex: 2,
+		},
+		{
+			name:      "last standing",
+			players:   PlayerList{{ID: "A"}, {ID: "B", Hit: true}, {ID: "C", Hit: true}},
+			index:     0,
+			wantIndex: -1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tindex, cooldown := tt.players.findTargetAfter(tt.index, epoch)
+			if tindex != tt.wantIndex || cooldown != tt.wantCooldown {
+				t.Errorf("findTargetAfter(%d) = %d, %t; want %d, %t",
+					tt.index, tindex, cooldown, tt.wantIndex, tt.wantCooldown)
+			}
+		})
+	}
+}
+
+func TestFindHuntedBy(t *testing.T) {
+	tests := []struct {
+		name    string
+		players PlayerList
+		index   int
+		want    int
+	}{
+		{
+			name:    "previous player",
+			players: PlayerList{{ID: "A"}, {ID: "B"}, {ID: "C"}},
+			index:   1,
+			want:    0,
+		},
+		{
+			name:    "wraps around",
+			players: PlayerList{{ID: "A"}, {ID: "B"}, {ID: "C"}},
+			index:   0,
+			want:    2,
+		},
+		{
+			name:    "skips the dead",
+			players: PlayerList{{ID: "A"}, {ID: "B", Hit: true}, {ID: "C"}},
+			index:   2,
+			want:    0,
+		},
+		{
+			name:    "skips teammates",
+			players: PlayerList{{ID: "A", Team: "blue"}, {ID: "B", Team: "red"}, {ID: "C", Team: "red"}},
+			index:   2,
+			want:    0,
+		},
+		{
+			name:    "nobody hunting",
+			players: PlayerList{{ID: "A"}, {ID: "B", Hit: true}, {ID: "C", Hit: true}},
+			index:   0,
+			want:    -1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.players.findHuntedBy(tt.index); got != tt.want {
+				t.Errorf("findHuntedBy(%d) = %d; want %d", tt.index, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCooldowns(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(ff *FireFight) error
+		after time.Duration
+		try   func(ff *FireFight) error
+		want  string // Error code, "" for none.
+	}{
+		{
+			name:  "hit cooldown running",
+			setup: func(ff *FireFight) error { _, err := ff.ReportHit("A"); return err },
+			after: HitCooldown - time.Second,
+			try:   func(ff *FireFight) error { _, err := ff.GetTarget("A"); return err },
+			want:  "on_cooldown",
+		},
+		{
+			name:  "hit cooldown over",
+			setup: func(ff *FireFight) error { _, err := ff.ReportHit("A"); return err },
+			after: HitCooldown,
+			try:   func(ff *FireFight) error { _, err := ff.GetTarget("A"); return err },
+		},
+		{
+			name:  "dispute on the last moment",
+			setup: func(ff *FireFight) error { _, err := ff.ReportHit("A"); return err },
+			after: HitCooldown,
+			try:   func(ff *FireFight) error { _, err := ff.DisputeHit("B"); return err },
+		},
+		{
+			name:  "dispute too late",
+			setup: func(ff *FireFight) error { _, err := ff.ReportHit("A"); return err },
+			after: HitCooldown + time.Nanosecond,
+			try:   func(ff *FireFight) error { _, err := ff.DisputeHit("B"); return err },
+			want:  "dispute_expired",
+		},
+		{
+			name:  "confirm too late",
+			setup: func(ff *FireFight) error { _, err := ff.ReportHit("A"); return err },
+			after: HitCooldown + time.Nanosecond,
+			try:   func(ff *FireFight) error { return ff.ConfirmHit("B") },
+			want:  "hit_final",
+		},
+		{
+			name:  "defensive cooldown running",
+			setup: func(ff *FireFight) error { _, err := ff.Defend("B"); return err },
+			after: DefensiveCooldown - time.Second,
+			try:   func(ff *FireFight) error { _, err := ff.ReportHit("A"); return err },
+			want:  "defensive_cooldown",
+		},
+		{
+			name:  "defensive cooldown over",
+			setup: func(ff *FireFight) error { _, err := ff.Defend("B"); return err },
+			after: DefensiveCooldown,
+			try:   func(ff *FireFight) error { _, err := ff.ReportHit("A"); return err },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ff, clock := newTestGame(t, "A", "B", "C")
+			if err := tt.setup(ff); err != nil {
+				t.Fatalf("setup: %v", err)
+			}
+
+			clock.Advance(tt.after)
+
+			err := tt.try(ff)
+			switch {
+			case tt.want == "" && err != nil:
+				t.Errorf("got %v; want no error", err)
+			case tt.want != "" && (err == nil || ErrorCode(err) != tt.want):
+				t.Errorf("got %v; want %s", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtendCooldowns(t *testing.T) {
+	from := epoch
+	pause := 10 * time.Minute
+
+	tests := []struct {
+		name    string
+		timeout time.Time
+		want    time.Time
+	}{
+		{"running", from.Add(time.Minute), from.Add(time.Minute + pause)},
+		{"ran out", from.Add(-time.Minute), from.Add(-time.Minute)},
+		{"ran out on pause", from, from},
+		{"never set", time.Time{}, time.Time{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pl := PlayerList{{ID: "A", HitTimeout: tt.timeout, DefensiveTimeout: tt.timeout, PendingTimeout: tt.timeout}}
+			pl.extendCooldowns(from, pause)
+
+			p := pl[0]
+			if !p.HitTimeout.Equal(tt.want) || !p.DefensiveTimeout.Equal(tt.want) || !p.PendingTimeout.Equal(tt.want) {
+				t.Errorf("got %s, %s, %s; want %s", p.HitTimeout, p.DefensiveTimeout, p.PendingTimeout, tt.want)
+			}
+		})
+	}
+}
+
+func TestPauseHoldsCooldowns(t *testing.T) {
+	ff, clock := newTestGame(t, "A", "B", "C")
+	if _, err := ff.ReportHit("A"); err != nil {
+		t.Fatal(err)
+	}
+
+	clock.Advance(time.Minute)
+	if err := ff.Pause(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Paused time doesn't count.
+	clock.Advance(time.Hour)
+	if _, err := ff.GetTarget("A"); ErrorCode(err) != "on_cooldown" {
+		t.Fatalf("GetTarget while paused: %v; want on_cooldown", err)
+	}
+
+	if err := ff.Start("A"); err != nil {
+		t.Fatal(err)
+	}
+
+	clock.Advance(HitCooldown - time.Minute - time.Second)
+	if _, err := ff.DisputeHit("B"); err != nil {
+		t.Fatalf("DisputeHit before the extended cooldown ran out: %v", err)
+	}
+}
+
+func TestLastPlayerLeft(t *testing.T) {
+	tests := []struct {
+		name   string
+		hit    bool // A hits B, then leaves.
+		winner string
+	}{
+		{"standing", false, "B"},
+		{"down", true, "B"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ff, clock := newTestGame(t, "A", "B")
+			if tt.hit {
+				if _, err := ff.ReportHit("A"); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if _, err := ff.Leave("A"); err != nil {
+				t.Fatal(err)
+			}
+
+			// A hit can be disputed until the cooldown runs out.
+			clock.Advance(HitCooldown)
+
+			if ff.CurrentState() != StateFinished || ff.Winner != tt.winner {
+				t.Errorf("state %s, winner %q; want finished, %q", ff.CurrentState(), ff.Winner, tt.winner)
+			}
+		})
+	}
+}
+
+func TestDisputeAfterRingReorder(t *testing.T) {
+	// Mid-game joins land at a random spot, so try a few.
+	for i := 0; i < 20; i++ {
+		ff, _ := newTestGame(t, "A", "B", "C", "D", "E")
+		if _, err := ff.ReportHit("A"); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := ff.ReportHit("C"); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := ff.Join("F", ""); err != nil {
+			t.Fatal(err)
+		}
+		if err := ff.Join("G", ""); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := ff.DisputeHit("B"); err != nil {
+			t.Fatal(err)
+		}
+
+		// Only the hit on B is taken back, from A who made it.
+		want := map[string]int{"C": 1}
+		for _, p := range ff.Players {
+			if p.Score != want[p.ID] {
+				t.Errorf("ring %v: %s has %d pts; want %d", ff.Players.ids(), p.ID, p.Score, want[p.ID])
+			}
+		}
+	}
+}
+
+func TestStartNeedsTwoPlayers(t *testing.T) {
+	ff := New()
+	if err := ff.Join("A", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ff.Start("A"); err != ErrTooFewPlayers {
+		t.Errorf("got %v; want %v", err, ErrTooFewPlayers)
+	}
+}
@@ -0,0 +1,37 @@
+package firefight
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// Logging
+//
+// Logs are structured JSON, see log/slog. Middleware gives every request an
+// ID and a logger carrying it, plus the channel, user, command and the state
+// the request found the game in. Handlers log through Logger so any line can
+// be traced back to the request that caused it. Outside requests, e.g. when
+// the timer fires, games log through their own logger, see SetLogger.
+
+// NewRequestID returns a random ID to tell requests apart in the logs.
+func NewRequestID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "unknown"
+	}
+
+	return hex.EncodeToString(b)
+}
+
+// refuse logs 'err' from the game and turns it into a reply to 'command'
+// only the sender sees, see Message.
+func refuse(ctx context.Context, command string, err error) SlackResponse {
+	if code := ErrorCode(err); code != "internal" {
+		Logger(ctx).Info("refused", "code", code, "error", err)
+	} else {
+		Logger(ctx).Error("failed", "error", err)
+	}
+
+	return SlackResponse{Type: "ephemeral", Text: Message(err, command)}
+}
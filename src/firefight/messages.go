@@ -0,0 +1,166 @@
+package firefight
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Messages
+//
+// What players are told when a move is refused, by error code. Some codes
+// are worded differently depending on the command, e.g. an idle game is a
+// ceasefire to someone trying to /ffhit.
+
+var messages = map[string]string{
+	"no_active_game":   "No active game.",
+	"game_paused":      "Game is paused.",
+	"game_over":        "Game over.",
+	"game_in_progress": "Game still in progress.",
+	"rules_locked":     "Rules can't change mid-game. /ffstart without rules to unpause.",
+
+	"not_player":      "Not in game.",
+	"already_joined":  "Already joined.",
+	"banned":          "You're banned from FireFights in this channel.",
+	"forfeited":       "You forfeited this game. Catch the next one.",
+	"team_required":   "This is a team game. Pick a side: /ffjoin <team>",
+	"no_teams":        "This is a free-for-all. No teams here: /ffjoin",
+	"bad_team_name":   "Team names are a single plain word.",
+	"too_few_teams":   "A team game needs at least two teams.",
+	"too_few_players": "A fight needs at least two players. /ffjoin first.",
+
+	"dead":            "No targets for the fallen.",
+	"no_target":       "No targets.",
+	"last_standing":   "No targets left. You're the last one standing!",
+	"target_disputed": "Slow down there, hotshot. A referee is looking at the last hit.",
+	"claim_taken":     "Someone beat you to it. Their hit is waiting on confirmation.",
+	"already_hit":     "You've already been hit. Can't defend.",
+	"not_hunted":      "Not being hunted.",
+	"not_hit":         "You haven't been hit.",
+	"awaiting_ruling": "Already waiting on a ruling.",
+	"dispute_expired": "This ones been sitting awhile and necromancy isn't my specialty.",
+	"hit_final":       "Hit already confirmed.",
+	"stale_hit":       "That hit has been settled. Answer the latest one.",
+
+	"admin_only":   "Only the game's owner or admins can do that.",
+	"owner_only":   "Only the game's owner can do that.",
+	"referee_only": "Only referees can do that.",
+	"no_owner":     "No game owner yet. /ffstart a game first.",
+	"is_owner":     "The owner can't be removed.",
+
+	"workspace_admin_only": "Nobody runs this game right now. Only a workspace admin can do that.",
+
+	"last_referee":           "Rule on the open disputes before removing the last referee.",
+	"own_fight":              "You can't rule on your own fight.",
+	"no_ruling":              "No hit waiting on a ruling.",
+	"no_mention":             "Mention a user, e.g. @someone.",
+	"respawn_needs_duration": "With respawns nobody stays down. Set a duration, e.g. duration=1h",
+}
+
+// commandMessages override messages for a command, see Named.
+var commandMessages = map[string]map[string]string{
+	"pause": {
+		"game_paused": "Game already paused.",
+		"game_over":   "Game over. /ffend or /ffstart a rematch.",
+	},
+	"end": {
+		"game_in_progress": "Cannot end active game. /ffpause first.",
+	},
+	"leave": {
+		"not_player": "You can't leave if you don't play.",
+	},
+	"target": {
+		"not_player": "You can't win if you don't play.",
+	},
+	"hit": {
+		"no_active_game": "Ceasefire! No active game.",
+		"game_paused":    "Ceasefire! Game is paused.",
+		"game_over":      "Ceasefire! Game over.",
+		"not_player":     "You can't win if you don't play.",
+		"dead":           "Martyrdom isn't a perk. You're dead.",
+		"no_target":      "No target to hit.",
+	},
+	"dispute": {
+		"not_player": "You can't lose if you don't play.",
+		"not_hit":    "It was only a scratch. You're still in this fight!",
+	},
+	"confirm": {
+		"not_player":      "You can't lose if you don't play.",
+		"awaiting_ruling": "Too late. Your hit is waiting on a ruling.",
+	},
+	"kick": {
+		"is_owner": "The owner can't be kicked.",
+	},
+	"ban": {
+		"is_owner": "The owner can't be banned.",
+	},
+}
+
+// userMessages are UserError messages, formatted with the user's ID.
+var userMessages = map[string]string{
+	"not_player":      "<@%s> is not in the game.",
+	"already_admin":   "<@%s> is already an admin.",
+	"not_admin":       "<@%s> is not an admin.",
+	"already_banned":  "<@%s> is already banned.",
+	"not_banned":      "<@%s> is not banned.",
+	"already_referee": "<@%s> is already a referee.",
+	"not_referee":     "<@%s> is not a referee.",
+	"no_dispute":      "No dispute over <@%s>.",
+}
+
+// Message returns what players are told about 'err' in reply to 'command',
+// e.g. "hit". Empty 'command' gives the default wording. Errors that aren't
+// refused moves are passed through as is.
+func Message(err error, command string) string {
+	var (
+		userErr  *UserError
+		cooldown *ErrOnCooldown
+		respawn  *ErrRespawning
+		claim    *ErrClaimPending
+		badRule  *ErrBadRule
+	)
+
+	switch {
+	case errors.As(err, &userErr):
+		if format, ok := userMessages[userErr.Code()]; ok {
+			return fmt.Sprintf(format, userErr.User)
+		}
+
+	case errors.As(err, &cooldown):
+		if cooldown.Defensive {
+			return fmt.Sprintf("In defensive cooldown. Can't attack. [%s]", cooldown.Remaining)
+		}
+
+		return fmt.Sprintf("Slow down there, hotshot. [%s]", cooldown.Remaining)
+
+	case errors.As(err, &respawn):
+		return fmt.Sprintf("No targets for the fallen. Respawning in [%s].", respawn.Remaining)
+
+	case errors.As(err, &claim):
+		return fmt.Sprintf("Your hit on <@%s> is still waiting on confirmation.", claim.Target)
+
+	case errors.As(err, &badRule):
+		switch {
+		case badRule.Rule == "":
+			return fmt.Sprintf("Expected rule=value, got %q.\n%s", badRule.Value, rulesUsage)
+		case badRule.Err == nil:
+			return fmt.Sprintf("Unknown rule %q.\n%s", badRule.Rule, rulesUsage)
+		}
+
+		return fmt.Sprintf("Bad %s %q: %s\n%s", badRule.Rule, badRule.Value, badRule.Err, rulesUsage)
+	}
+
+	code := ErrorCode(err)
+	if code == "internal" {
+		return err.Error()
+	}
+
+	if text, ok := commandMessages[command][code]; ok {
+		return text
+	}
+
+	if text, ok := messages[code]; ok {
+		return text
+	}
+
+	return code
+}
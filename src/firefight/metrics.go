@@ -0,0 +1,160 @@
+package firefight
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Metrics
+//
+// Just enough of the Prometheus text format to be scraped, without pulling
+// in the client library.
+// https://prometheus.io/docs/instrumenting/exposition_formats/
+
+// Labels formats label pairs, e.g. Labels("command", "hit") is
+// '{command="hit"}'.
+func Labels(pairs ...string) string {
+	if len(pairs) == 0 {
+		return ""
+	}
+
+	parts := make([]string, 0, len(pairs)/2)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		parts = append(parts, fmt.Sprintf("%s=%q", pairs[i], pairs[i+1]))
+	}
+
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+// Counter only goes up. Each set of labels is its own series.
+type Counter struct {
+	Name string
+	Help string
+
+	mu     sync.Mutex
+	values map[string]uint64
+}
+
+func NewCounter(name, help string) *Counter {
+	return &Counter{Name: name, Help: help, values: make(map[string]uint64)}
+}
+
+// Inc adds one to the series with 'labels', see Labels.
+func (c *Counter) Inc(labels string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.values[labels]++
+}
+
+func (c *Counter) Write(w io.Writer) {
+	c.mu.Lock()
+	values := make(map[string]float64, len(c.values))
+	for labels, v := range c.values {
+		values[labels] = float64(v)
+	}
+	c.mu.Unlock()
+
+	WriteMetric(w, c.Name, c.Help, "counter", values)
+}
+
+// DefaultBuckets are upper bounds in seconds, as used by Prometheus clients.
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Histogram counts observations into buckets. Each set of labels is its own
+// series.
+type Histogram struct {
+	Name    string
+	Help    string
+	Buckets []float64 // Ascending.
+
+	mu     sync.Mutex
+	series map[string]*histogramSeries
+}
+
+type histogramSeries struct {
+	counts []uint64 // Per bucket, not cumulative.
+	sum    float64
+	count  uint64
+}
+
+func NewHistogram(name, help string, buckets []float64) *Histogram {
+	return &Histogram{Name: name, Help: help, Buckets: buckets, series: make(map[string]*histogramSeries)}
+}
+
+// Observe records 'v' in the series with 'labels', see Labels.
+func (h *Histogram) Observe(labels string, v float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	s, ok := h.series[labels]
+	if !ok {
+		s = &histogramSeries{counts: make([]uint64, len(h.Buckets))}
+		h.series[labels] = s
+	}
+
+	if i := sort.SearchFloat64s(h.Buckets, v); i < len(h.Buckets) {
+		s.counts[i]++
+	}
+	s.sum += v
+	s.count++
+}
+
+func (h *Histogram) Write(w io.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s histogram\n", h.Name, h.Help, h.Name)
+
+	keys := make([]string, 0, len(h.series))
+	for labels := range h.series {
+		keys = append(keys, labels)
+	}
+	sort.Strings(keys)
+
+	for _, labels := range keys {
+		s := h.series[labels]
+
+		var cumulative uint64
+		for i, le := range h.Buckets {
+			cumulative += s.counts[i]
+			fmt.Fprintf(w, "%s_bucket%s %d\n", h.Name, withLabel(labels, "le", fmt.Sprint(le)), cumulative)
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", h.Name, withLabel(labels, "le", "+Inf"), s.count)
+		fmt.Fprintf(w, "%s_sum%s %g\n", h.Name, labels, s.sum)
+		fmt.Fprintf(w, "%s_count%s %d\n", h.Name, labels, s.count)
+	}
+}
+
+// WriteMetric writes one metric of type 'typ' with a value per set of labels.
+// Used for gauges worked out at scrape time.
+func WriteMetric(w io.Writer, name, help, typ string, values map[string]float64) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+
+	keys := make([]string, 0, len(values))
+	for labels := range values {
+		keys = append(keys, labels)
+	}
+	sort.Strings(keys)
+
+	for _, labels := range keys {
+		fmt.Fprintf(w, "%s%s %g\n", name, labels, values[labels])
+	}
+}
+
+// withLabel adds one more label pair to formatted 'labels'.
+func withLabel(labels, name, value string) string {
+	pair := Labels(name, value)
+	if labels == "" {
+		return pair
+	}
+
+	return strings.TrimSuffix(labels, "}") + "," + strings.TrimPrefix(pair, "{")
+}
+
+// EventsTotal counts every change made to any game by event type, e.g.
+// hits, disputes, defends and joins.
+var EventsTotal = NewCounter("firefight_events_total", "Game events recorded, by type.")
@@ -0,0 +1,71 @@
+package firefight
+
+// Kicks and bans
+//
+// Admins can take a troublemaker out of the ring at any stage. Their hunter
+// inherits their target and hits they made can no longer be taken back.
+// Bans also keep them out of every later game in the channel until lifted,
+// and from starting one to take it over.
+
+// Kick lets admin 'by' take player 'id' out of the game.
+func (ff *FireFight) Kick(by, id string) error {
+	ff.mu.Lock()
+	defer ff.mu.Unlock()
+
+	if id == ff.Owner {
+		return ErrIsOwner
+	}
+
+	if ff.find(id) == -1 {
+		return &UserError{Err: ErrNotPlayer, User: id}
+	}
+
+	return ff.record(Event{Time: ff.clock.Now(), Type: EventRemove, Target: id, Admin: by})
+}
+
+// Ban lets admin 'by' kick 'id', if playing, and keep them from joining.
+func (ff *FireFight) Ban(by, id string) error {
+	ff.mu.Lock()
+	defer ff.mu.Unlock()
+
+	if id == ff.Owner {
+		return ErrIsOwner
+	}
+
+	if ff.isBanned(id) {
+		return &UserError{Err: ErrAlreadyBanned, User: id}
+	}
+
+	return ff.record(Event{Time: ff.clock.Now(), Type: EventBan, Target: id, Admin: by})
+}
+
+// Unban lets admin 'by' lift the ban on 'id'.
+func (ff *FireFight) Unban(by, id string) error {
+	ff.mu.Lock()
+	defer ff.mu.Unlock()
+
+	if !ff.isBanned(id) {
+		return &UserError{Err: ErrNotBanned, User: id}
+	}
+
+	return ff.record(Event{Time: ff.clock.Now(), Type: EventUnban, Target: id, Admin: by})
+}
+
+// BannedIDs returns everyone banned from the channel's games.
+func (ff *FireFight) BannedIDs() []string {
+	ff.mu.RLock()
+	defer ff.mu.RUnlock()
+
+	return append([]string(nil), ff.Banned...)
+}
+
+// ff.mu must be held.
+func (ff *FireFight) isBanned(id string) bool {
+	for _, banned := range ff.Banned {
+		if banned == id {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,56 @@
+package firefight
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestBanOutlastsOwner(t *testing.T) {
+	ff, _ := newTestGame(t, "A", "B", "C")
+
+	cmd := func(user, text string) *SlackCmd {
+		return &SlackCmd{TeamID: "T1", ChannelID: "C1", UserID: user, Text: text}
+	}
+
+	if _, err := Ban(ff, cmd("A", "<@B>")); err != nil {
+		t.Fatalf("owner banning: %v", err)
+	}
+
+	if err := ff.Pause(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := ff.End(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Nobody owns the game now, which used to make it anyone's.
+	tests := []struct {
+		name    string
+		handler CommandFunc
+		user    string
+		text    string
+	}{
+		{"unban self", Unban, "B", "<@B>"},
+		{"unban", Unban, "C", "<@B>"},
+		{"ban", Ban, "C", "<@A>"},
+		{"kick", Kick, "C", "<@A>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.handler(ff, cmd(tt.user, tt.text)); !errors.Is(err, ErrWorkspaceAdminOnly) {
+				t.Errorf("got %v; want %v", err, ErrWorkspaceAdminOnly)
+			}
+		})
+	}
+
+	if err := ff.Join("B", ""); !errors.Is(err, ErrBanned) {
+		t.Errorf("Join: %v; want %v", err, ErrBanned)
+	}
+
+	// Nor can they start a game to own it.
+	if err := ff.Start("B"); !errors.Is(err, ErrBanned) {
+		t.Errorf("Start: %v; want %v", err, ErrBanned)
+	}
+}
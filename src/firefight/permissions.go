@@ -0,0 +1,226 @@
+package firefight
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// Permissions
+//
+// Whoever starts a game from the lobby owns it. The owner can name admins,
+// and together they control the game: start, pause, end, reset and rules.
+// A game without an owner is anyone's, e.g. the lobby before the first
+// start. Slack workspace admins can always step in.
+//
+// Kicks and bans are the exception. Bans outlast the game, so once nobody
+// owns it only workspace admins can kick, ban or unban.
+
+// IsAdmin reports if 'id' may control the game.
+func (ff *FireFight) IsAdmin(id string) bool {
+	ff.mu.RLock()
+	defer ff.mu.RUnlock()
+
+	return ff.isAdmin(id)
+}
+
+// ff.mu must be held.
+func (ff *FireFight) isAdmin(id string) bool {
+	if ff.isOwner(id) {
+		return true
+	}
+
+	for _, admin := range ff.Admins {
+		if admin == id {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsOwner reports if 'id' owns the game.
+func (ff *FireFight) IsOwner(id string) bool {
+	ff.mu.RLock()
+	defer ff.mu.RUnlock()
+
+	return ff.isOwner(id)
+}
+
+// ff.mu must be held.
+func (ff *FireFight) isOwner(id string) bool {
+	return ff.Owner == "" || ff.Owner == id
+}
+
+// IsModerator reports if 'id' may kick and ban, see permitModerator.
+func (ff *FireFight) IsModerator(id string) bool {
+	ff.mu.RLock()
+	defer ff.mu.RUnlock()
+
+	return ff.Owner != "" && ff.isAdmin(id)
+}
+
+// AddAdmin records 'by' naming 'id' an admin of the game.
+func (ff *FireFight) AddAdmin(by, id string) error {
+	ff.mu.Lock()
+	defer ff.mu.Unlock()
+
+	if ff.Owner == "" {
+		return ErrNoOwner
+	}
+
+	if ff.isAdmin(id) {
+		return &UserError{Err: ErrAlreadyAdmin, User: id}
+	}
+
+	return ff.record(Event{Time: ff.clock.Now(), Type: EventAdmin, Player: by, Target: id})
+}
+
+// RemoveAdmin records 'by' dropping admin 'id'. The owner stays.
+func (ff *FireFight) RemoveAdmin(by, id string) error {
+	ff.mu.Lock()
+	defer ff.mu.Unlock()
+
+	if id == ff.Owner {
+		return ErrIsOwner
+	}
+
+	if !ff.isAdmin(id) {
+		return &UserError{Err: ErrNotAdmin, User: id}
+	}
+
+	return ff.record(Event{Time: ff.clock.Now(), Type: EventUnadmin, Player: by, Target: id})
+}
+
+// AdminIDs returns the owner followed by the other admins.
+func (ff *FireFight) AdminIDs() []string {
+	ff.mu.RLock()
+	defer ff.mu.RUnlock()
+
+	if ff.Owner == "" {
+		return nil
+	}
+
+	return append([]string{ff.Owner}, ff.Admins...)
+}
+
+// permit returns an error unless the sender of 'scmd' controls 'ff'.
+func permit(ff *FireFight, scmd *SlackCmd) error {
+	if ff.IsAdmin(scmd.UserID) || isWorkspaceAdmin(ff, scmd) {
+		return nil
+	}
+
+	return ErrAdminOnly
+}
+
+// permitOwner returns an error unless the sender of 'scmd' owns 'ff'.
+func permitOwner(ff *FireFight, scmd *SlackCmd) error {
+	if ff.IsOwner(scmd.UserID) || isWorkspaceAdmin(ff, scmd) {
+		return nil
+	}
+
+	return ErrOwnerOnly
+}
+
+// permitModerator returns an error unless the sender of 'scmd' may kick and
+// ban in 'ff'. Unlike permit, a game without an owner isn't anyone's here.
+func permitModerator(ff *FireFight, scmd *SlackCmd) error {
+	if ff.IsModerator(scmd.UserID) || isWorkspaceAdmin(ff, scmd) {
+		return nil
+	}
+
+	if len(ff.AdminIDs()) == 0 {
+		return ErrWorkspaceAdminOnly
+	}
+
+	return ErrAdminOnly
+}
+
+// isWorkspaceAdmin looks the sender of 'scmd' up with the bot token of
+// their workspace. Without a token nobody overrides game permissions.
+func isWorkspaceAdmin(ff *FireFight, scmd *SlackCmd) bool {
+	api := Workspaces.For(scmd.TeamID).API()
+	if api == nil {
+		return false
+	}
+
+	admin, err := api.IsAdmin(scmd.UserID)
+	if err != nil {
+		ff.Logger().Error("users.info failed", "team", scmd.TeamID, "user", scmd.UserID, "error", err)
+		return false
+	}
+
+	return admin
+}
+
+// SlackAPI calls the Slack Web API with a bot token.
+// Needs the users:read scope.
+type SlackAPI struct {
+	Token string
+
+	mu     sync.Mutex
+	admins map[string]cachedAdmin
+}
+
+type cachedAdmin struct {
+	admin   bool
+	expires time.Time
+}
+
+// adminCacheTTL is how long a users.info lookup is trusted.
+const adminCacheTTL = 10 * time.Minute
+
+const slackAPIURL = "https://slack.com/api/"
+
+// IsAdmin reports if 'userID' is an admin or owner of the workspace.
+func (api *SlackAPI) IsAdmin(userID string) (bool, error) {
+	api.mu.Lock()
+	cached, ok := api.admins[userID]
+	api.mu.Unlock()
+
+	if ok && time.Now().Before(cached.expires) {
+		return cached.admin, nil
+	}
+
+	req, err := http.NewRequest("GET", slackAPIURL+"users.info?user="+url.QueryEscape(userID), nil)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Authorization", "Bearer "+api.Token)
+
+	resp, err := slackClient.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	var v struct {
+		OK    bool   `json:"ok"`
+		Error string `json:"error"`
+		User  struct {
+			IsAdmin bool `json:"is_admin"`
+			IsOwner bool `json:"is_owner"`
+		} `json:"user"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&v); err != nil {
+		return false, err
+	}
+
+	if !v.OK {
+		return false, fmt.Errorf("users.info: %s", v.Error)
+	}
+
+	admin := v.User.IsAdmin || v.User.IsOwner
+
+	api.mu.Lock()
+	if api.admins == nil {
+		api.admins = make(map[string]cachedAdmin)
+	}
+	api.admins[userID] = cachedAdmin{admin: admin, expires: time.Now().Add(adminCacheTTL)}
+	api.mu.Unlock()
+
+	return admin, nil
+}
@@ -0,0 +1,202 @@
+package firefight
+
+import (
+	"fmt"
+	"time"
+)
+
+// Referees
+//
+// Once a game has referees, disputing a hit no longer revives on the spot.
+// The hit stays on the books, and in the dispute queue, until a referee
+// upholds or overturns it. Scores only change with the ruling. Pending
+// claims that went to a ruling are queued the same way.
+//
+// Referees are named and dropped by the game's admins, like its rules.
+
+// Dispute is a hit waiting on a ruling.
+type Dispute struct {
+	Player   string // Hit player.
+	Attacker string `json:",omitempty"`
+	Claim    bool   `json:",omitempty"` // Pending claim rather than a disputed hit.
+}
+
+// isReferee reports if 'id' is a referee of this game.
+//
+// ff.mu must be held.
+func (ff *FireFight) isReferee(id string) bool {
+	for _, ref := range ff.Referees {
+		if ref == id {
+			return true
+		}
+	}
+
+	return false
+}
+
+// AddReferee records admin 'by' naming 'id' a referee.
+func (ff *FireFight) AddReferee(by, id string) error {
+	ff.mu.Lock()
+	defer ff.mu.Unlock()
+
+	if ff.isReferee(id) {
+		return &UserError{Err: ErrAlreadyReferee, User: id}
+	}
+
+	return ff.record(Event{Time: ff.clock.Now(), Type: EventReferee, Player: by, Target: id})
+}
+
+// RemoveReferee records admin 'by' dropping referee 'id'.
+func (ff *FireFight) RemoveReferee(by, id string) error {
+	ff.mu.Lock()
+	defer ff.mu.Unlock()
+
+	if !ff.isReferee(id) {
+		return &UserError{Err: ErrNotReferee, User: id}
+	}
+
+	if len(ff.Referees) == 1 && len(ff.disputes(ff.gameTime(ff.clock.Now()))) > 0 {
+		return ErrLastReferee
+	}
+
+	return ff.record(Event{Time: ff.clock.Now(), Type: EventUnreferee, Player: by, Target: id})
+}
+
+// RefereeIDs returns the referees of this game.
+func (ff *FireFight) RefereeIDs() []string {
+	ff.mu.RLock()
+	defer ff.mu.RUnlock()
+
+	return append([]string(nil), ff.Referees...)
+}
+
+// Disputes returns every hit waiting on a ruling, in ring order.
+func (ff *FireFight) Disputes() []Dispute {
+	ff.mu.RLock()
+	defer ff.mu.RUnlock()
+
+	return ff.disputes(ff.gameTime(ff.clock.Now()))
+}
+
+// ff.mu must be held.
+func (ff *FireFight) disputes(now time.Time) []Dispute {
+	var queue []Dispute
+	for _, p := range ff.Players {
+		switch {
+		case p.awaitingRuling(now):
+			queue = append(queue, Dispute{Player: p.ID, Attacker: p.PendingBy, Claim: true})
+		case p.Disputed:
+			queue = append(queue, Dispute{Player: p.ID, Attacker: p.HitBy})
+		}
+	}
+
+	return queue
+}
+
+// Rule lets 'referee' uphold or overturn the hit on player 'id' and returns
+// the ruling to announce. Referees can't rule on their own hits.
+func (ff *FireFight) Rule(referee, id string, uphold bool) (string, error) {
+	ff.mu.Lock()
+	defer ff.mu.Unlock()
+
+	if !ff.isReferee(referee) {
+		return "", ErrRefereeOnly
+	}
+
+	d, err := ff.findDispute(id)
+	if err != nil {
+		return "", err
+	}
+
+	if referee == d.Player || referee == d.Attacker {
+		return "", ErrOwnFight
+	}
+
+	e := Event{Time: ff.clock.Now(), Type: EventOverturn, Player: id, Referee: referee}
+	if uphold {
+		e.Type = EventAccept
+	}
+
+	if err := ff.record(e); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("Referee <@%s>: %s", referee, rulingText(d, uphold)), nil
+}
+
+// ResolveHit rules on the hit against player 'id' as an admin, whether or
+// not it's in the dispute queue, and announces the ruling. Upheld hits
+// become final, overturned ones are taken back.
+func (ff *FireFight) ResolveHit(admin, id string, uphold bool) error {
+	ff.mu.Lock()
+	defer ff.mu.Unlock()
+
+	if ff.State == StateIdle {
+		return ErrNoActiveGame
+	}
+
+	index := ff.find(id)
+	if index == -1 {
+		return ErrNotPlayer
+	}
+
+	p := ff.Players[index]
+	if p.PendingBy == "" && !p.Disputed {
+		return ErrNoRuling
+	}
+
+	d := Dispute{Player: id, Attacker: p.PendingBy, Claim: p.PendingBy != ""}
+	if p.Disputed {
+		d.Attacker = p.HitBy
+	}
+
+	e := Event{Time: ff.clock.Now(), Type: EventOverturn, Player: id, Admin: admin}
+	if uphold {
+		e.Type = EventAccept
+	}
+
+	if err := ff.record(e); err != nil {
+		return err
+	}
+
+	ff.announce("Admin ruling: " + rulingText(d, uphold))
+
+	return nil
+}
+
+// findDispute returns the queued dispute over player 'id'.
+//
+// ff.mu must be held.
+func (ff *FireFight) findDispute(id string) (Dispute, error) {
+	switch ff.State {
+	case StateIdle:
+		return Dispute{}, ErrNoActiveGame
+	case StateFinished:
+		return Dispute{}, ErrGameOver
+	}
+
+	for _, d := range ff.disputes(ff.gameTime(ff.clock.Now())) {
+		if d.Player == id {
+			return d, nil
+		}
+	}
+
+	return Dispute{}, &UserError{Err: ErrNoDispute, User: id}
+}
+
+// rulingText tells attacker and hit player how 'd' was ruled.
+func rulingText(d Dispute, uphold bool) string {
+	switch {
+	case uphold && d.Attacker != "":
+		return fmt.Sprintf("<@%s>'s hit on <@%s> stands.", d.Attacker, d.Player)
+	case uphold:
+		return fmt.Sprintf("The hit on <@%s> stands.", d.Player)
+	case d.Claim:
+		return fmt.Sprintf("<@%s>'s hit on <@%s> is overturned. No point.", d.Attacker, d.Player)
+	case d.Attacker != "":
+		return fmt.Sprintf("<@%s>'s hit on <@%s> is overturned. <@%s> is back in the fight!",
+			d.Attacker, d.Player, d.Player)
+	}
+
+	return fmt.Sprintf("The hit on <@%s> is overturned. <@%s> is back in the fight!", d.Player, d.Player)
+}
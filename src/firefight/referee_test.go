@@ -0,0 +1,42 @@
+package firefight
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNamingReferees(t *testing.T) {
+	ff, _ := newTestGame(t, "A", "B", "C")
+
+	ref := func(user, text string) error {
+		_, err := Referee(ff, &SlackCmd{TeamID: "T1", ChannelID: "C1", UserID: user, Text: text})
+		return err
+	}
+
+	if err := ref("A", "add <@R>"); err != nil {
+		t.Fatalf("owner naming a referee: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		user string
+		text string
+	}{
+		{"player adds", "B", "add <@S>"},
+		{"player removes", "B", "remove <@R>"},
+		{"referee adds", "R", "add <@S>"},
+		{"referee removes", "R", "remove <@R>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ref(tt.user, tt.text); !errors.Is(err, ErrAdminOnly) {
+				t.Errorf("got %v; want %v", err, ErrAdminOnly)
+			}
+		})
+	}
+
+	if err := ref("A", "remove <@R>"); err != nil {
+		t.Fatalf("owner dropping a referee: %v", err)
+	}
+}
@@ -0,0 +1,128 @@
+package firefight
+
+import (
+	"fmt"
+	"strings"
+)
+
+const adminUsage = `Usage: /ffadmin <command>
+  add @user     Let @user help run the game. Owner only.
+  remove @user  Take that back. Owner only.
+  list          Show the owner and admins.
+  help          Show this message.`
+
+// Admin handles '/ffadmin'.
+func Admin(ff *FireFight, scmd *SlackCmd) (SlackResponse, error) {
+	fields := strings.Fields(scmd.Text)
+	if len(fields) == 0 {
+		return SlackResponse{Type: "ephemeral", Text: adminUsage}, nil
+	}
+
+	switch action := strings.ToLower(fields[0]); action {
+	case "help":
+		return SlackResponse{Type: "ephemeral", Text: adminUsage}, nil
+
+	case "list":
+		return SlackResponse{Type: "ephemeral", Text: adminsText(ff.AdminIDs())}, nil
+
+	case "add", "remove":
+		if len(fields) != 2 {
+			return SlackResponse{Type: "ephemeral", Text: adminUsage}, nil
+		}
+
+		if err := permitOwner(ff, scmd); err != nil {
+			return SlackResponse{}, err
+		}
+
+		id, err := ParseUserID(fields[1])
+		if err != nil {
+			return SlackResponse{}, err
+		}
+
+		text := fmt.Sprintf("<@%s> is now a game admin.", id)
+		if action == "add" {
+			err = ff.AddAdmin(scmd.UserID, id)
+		} else {
+			err = ff.RemoveAdmin(scmd.UserID, id)
+			text = fmt.Sprintf("<@%s> is no longer a game admin.", id)
+		}
+
+		if err != nil {
+			return SlackResponse{}, err
+		}
+
+		return SlackResponse{Type: "in_channel", Text: text}, nil
+	}
+
+	return SlackResponse{
+		Type: "ephemeral",
+		Text: fmt.Sprintf("Unknown command %q.\n%s", fields[0], adminUsage),
+	}, nil
+}
+
+func adminsText(ids []string) string {
+	if len(ids) == 0 {
+		return "Nobody owns this game yet. Whoever starts it will."
+	}
+
+	text := fmt.Sprintf("Owner: <@%s>", ids[0])
+	if len(ids) > 1 {
+		mentions := make([]string, len(ids)-1)
+		for i, id := range ids[1:] {
+			mentions[i] = fmt.Sprintf("<@%s>", id)
+		}
+
+		text += "\nAdmins: " + strings.Join(mentions, ", ")
+	}
+
+	return text
+}
+
+func Kick(ff *FireFight, scmd *SlackCmd) (SlackResponse, error) {
+	return moderate(ff, scmd, ff.Kick, "<@%s> was kicked from the game.")
+}
+
+// Ban bans the mentioned user, or lists bans without one.
+func Ban(ff *FireFight, scmd *SlackCmd) (SlackResponse, error) {
+	if strings.TrimSpace(scmd.Text) == "" {
+		return SlackResponse{Type: "ephemeral", Text: bannedText(ff.BannedIDs())}, nil
+	}
+
+	return moderate(ff, scmd, ff.Ban, "<@%s> is banned from FireFights in this channel.")
+}
+
+func Unban(ff *FireFight, scmd *SlackCmd) (SlackResponse, error) {
+	return moderate(ff, scmd, ff.Unban, "<@%s> is no longer banned.")
+}
+
+// moderate runs admin action 'fn' on the user mentioned in 'scmd' and
+// announces it with 'format'.
+func moderate(ff *FireFight, scmd *SlackCmd, fn func(by, id string) error, format string) (SlackResponse, error) {
+	if err := permitModerator(ff, scmd); err != nil {
+		return SlackResponse{}, err
+	}
+
+	id, err := ParseUserID(scmd.Text)
+	if err != nil {
+		return SlackResponse{}, err
+	}
+
+	if err := fn(scmd.UserID, id); err != nil {
+		return SlackResponse{}, err
+	}
+
+	return SlackResponse{Type: "in_channel", Text: fmt.Sprintf(format, id)}, nil
+}
+
+func bannedText(ids []string) string {
+	if len(ids) == 0 {
+		return "Nobody is banned."
+	}
+
+	mentions := make([]string, len(ids))
+	for i, id := range ids {
+		mentions[i] = fmt.Sprintf("<@%s>", id)
+	}
+
+	return "Banned: " + strings.Join(mentions, ", ")
+}
@@ -0,0 +1,94 @@
+package firefight
+
+import (
+	"fmt"
+	"strings"
+)
+
+const usage = `Usage: /ff <command>
+  start    Start a new game or unpause. Takes rules for a new game,
+           e.g. hit-cooldown=10m defend-cooldown=1d shuffle=off
+           Deathmatch: respawn=10m duration=1d
+  pause    Pause the game in progress.
+  end      End a paused or finished game.
+  reset    Throw away the game and its lobby.
+  admin    Name game admins. /ff admin help
+  kick     Take @user out of the game.
+  ban      Kick @user and keep them out. Lists bans without a user.
+  unban    Let @user back in.
+  join     Join the game. Name a team to play a team game.
+  leave    Leave the game. Your points are kept as forfeited.
+  target   Show your next target.
+  hit      Report a hit on your target.
+  dispute  Dispute a hit on you.
+  confirm  Confirm a hit on you.
+  defend   Report a defended attack.
+  ref      Name referees and rule on disputes. /ff ref help
+  score    Show the scoreboard.
+  help     Show this message.`
+
+// subcommands routes '/ff <name>' to the handler of the matching single
+// command, e.g. '/ff hit' is '/ffhit'.
+var subcommands = map[string]CommandFunc{
+	"start":   Start,
+	"pause":   Pause,
+	"end":     End,
+	"reset":   Reset,
+	"admin":   Admin,
+	"kick":    Kick,
+	"ban":     Ban,
+	"unban":   Unban,
+	"join":    Join,
+	"leave":   Leave,
+	"target":  Target,
+	"hit":     ReportHit,
+	"dispute": DisputeHit,
+	"confirm": ConfirmHit,
+	"defend":  DefendAttack,
+	"ref":     Referee,
+	"score":   Scoreboard,
+	"help":    Help,
+}
+
+// subcommandName names the subcommand '/ff <text>' runs. Anything unknown
+// is "unknown".
+func subcommandName(text string) string {
+	fields := strings.Fields(text)
+	if len(fields) == 0 {
+		return "help"
+	}
+
+	name := strings.ToLower(fields[0])
+	if _, ok := subcommands[name]; !ok {
+		return "unknown"
+	}
+
+	return name
+}
+
+// Command handles '/ff'. The first word of the text picks the subcommand and
+// the rest is passed on as its text.
+func Command(ff *FireFight, scmd *SlackCmd) (SlackResponse, error) {
+	fields := strings.Fields(scmd.Text)
+	if len(fields) == 0 {
+		return Help(ff, scmd)
+	}
+
+	name := strings.ToLower(fields[0])
+	handler, ok := subcommands[name]
+	if !ok {
+		return SlackResponse{
+			Type: "ephemeral",
+			Text: fmt.Sprintf("Unknown command %q.\n%s", name, usage),
+		}, nil
+	}
+
+	sub := *scmd
+	sub.Text = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(scmd.Text), fields[0]))
+
+	return handler(ff, &sub)
+}
+
+func Help(ff *FireFight, scmd *SlackCmd) (SlackResponse, error) {
+	return SlackResponse{Type: "ephemeral", Text: usage}, nil
+}
@@ -0,0 +1,41 @@
+package firefight
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRefusalsByRouteName(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler NamedCommand
+		text    string
+		want    string
+	}{
+		{"single command", Named("hit", ReportHit), "", "Ceasefire! No active game."},
+		{"subcommand", Named("", Command), "hit", "Ceasefire! No active game."},
+		{"other name", Named("target", ReportHit), "", "No active game."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The slash command Slack sent doesn't matter, only the route.
+			scmd := &SlackCmd{TeamID: "T1", ChannelID: "C1", UserID: "A", Command: "/renamed", Text: tt.text}
+
+			r := httptest.NewRequest("POST", "/endpoint/renamed", nil)
+			r = r.WithContext(WithSlackCmd(WithFireFight(r.Context(), New()), scmd))
+			w := httptest.NewRecorder()
+			tt.handler.ServeHTTP(w, r)
+
+			var resp SlackResponse
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatal(err)
+			}
+
+			if resp.Text != tt.want {
+				t.Errorf("got %q; want %q", resp.Text, tt.want)
+			}
+		})
+	}
+}
@@ -1,114 +1,130 @@
 package firefight
 
 import (
-	"encoding/json"
 	"fmt"
-	"log"
-	"net/http"
 	"strings"
 )
 
 type SlackResponse struct {
 	Type string `json:"response_type,omitempty"`
-	Text string `json:"text,omitempty"`
+	Text string `json:"text,omitempty"` // Fallback for notifications when Blocks are set.
+
+	Blocks []Block `json:"blocks,omitempty"`
+
+	// Only used when posting to a response_url.
+	ReplaceOriginal bool `json:"replace_original,omitempty"`
 }
 
-func Start(w http.ResponseWriter, r *http.Request) {
-	ff, ok := r.Context().Value("fire_fight").(*FireFight)
-	if !ok {
-		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
-		return
+func Start(ff *FireFight, scmd *SlackCmd) (SlackResponse, error) {
+	if err := permit(ff, scmd); err != nil {
+		return SlackResponse{}, err
 	}
 
-	var data SlackResponse
-	if err := ff.Start(); err != nil {
-		data = SlackResponse{Type: "ephemeral", Text: err.Error()}
+	if strings.TrimSpace(scmd.Text) == "" {
+		if err := ff.Start(scmd.UserID); err != nil {
+			return SlackResponse{}, err
+		}
 	} else {
-		data = SlackResponse{
-			Type: "in_channel",
-			Text: "FireFight Started!",
+		cfg, err := ParseRules(ff.Defaults(), scmd.Text)
+		if err != nil {
+			return SlackResponse{}, err
 		}
-	}
 
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(data); err != nil {
-		log.Println("[Start]", err)
+		if err := ff.StartWithConfig(scmd.UserID, cfg); err != nil {
+			return SlackResponse{}, err
+		}
 	}
+
+	return SlackResponse{
+		Type: "in_channel",
+		Text: fmt.Sprintf("FireFight Started!\nRules: %s", ff.Rules()),
+	}, nil
 }
 
-func Pause(w http.ResponseWriter, r *http.Request) {
-	ff, ok := r.Context().Value("fire_fight").(*FireFight)
-	if !ok {
-		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
-		return
+func Pause(ff *FireFight, scmd *SlackCmd) (SlackResponse, error) {
+	if err := permit(ff, scmd); err != nil {
+		return SlackResponse{}, err
 	}
 
-	var data SlackResponse
 	if err := ff.Pause(); err != nil {
-		data = SlackResponse{Type: "ephemeral", Text: err.Error()}
-	} else {
-		data = SlackResponse{
-			Type: "in_channel",
-			Text: "[Paused] Ceasefire!",
-		}
+		return SlackResponse{}, err
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(data); err != nil {
-		log.Println("[Pause]", err)
-	}
+	return SlackResponse{
+		Type: "in_channel",
+		Text: "[Paused] Ceasefire!",
+	}, nil
 }
 
-func End(w http.ResponseWriter, r *http.Request) {
-	ff, ok := r.Context().Value("fire_fight").(*FireFight)
-	if !ok {
-		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
-		return
+func End(ff *FireFight, scmd *SlackCmd) (SlackResponse, error) {
+	if err := permit(ff, scmd); err != nil {
+		return SlackResponse{}, err
 	}
 
-	var data SlackResponse
-	if players, err := ff.End(); err != nil {
-		data = SlackResponse{Type: "ephemeral", Text: err.Error()}
-	} else {
-		var finalScores strings.Builder
-		finalScores.WriteString("[FireFight Scoreboard]\n")
-		for i, p := range players {
-			finalScores.WriteString(fmt.Sprintf("#%d: % 2dpts - <@%s>\n", i+1, p.Score, p.ID))
-		}
+	players, teams, err := ff.End()
+	if err != nil {
+		return SlackResponse{}, err
+	}
 
-		data = SlackResponse{Type: "in_channel", Text: finalScores.String()}
+	text := scoreboardText(players)
+	if len(teams) > 0 {
+		text = teamScoreboardText(teams) + text
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(data); err != nil {
-		log.Println("[End]", err)
+	return SlackResponse{Type: "in_channel", Text: text}, nil
+}
+
+// Reset throws away the game and its lobby, whatever state it's in.
+func Reset(ff *FireFight, scmd *SlackCmd) (SlackResponse, error) {
+	if err := permit(ff, scmd); err != nil {
+		return SlackResponse{}, err
+	}
+
+	if err := ff.Reset(scmd.UserID); err != nil {
+		return SlackResponse{}, err
 	}
+
+	return SlackResponse{
+		Type: "in_channel",
+		Text: fmt.Sprintf("<@%s> reset the game. /ffjoin to play again.", scmd.UserID),
+	}, nil
 }
 
-func Scoreboard(w http.ResponseWriter, r *http.Request) {
-	ff, ok := r.Context().Value("fire_fight").(*FireFight)
-	if !ok {
-		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
-		return
+// scoreboardText formats the final scores.
+func scoreboardText(players []Player) string {
+	var finalScores strings.Builder
+	finalScores.WriteString("[FireFight Scoreboard]\n")
+	for i, p := range players {
+		if p.Forfeited {
+			finalScores.WriteString(fmt.Sprintf("#%d: % 2dpts - <@%s> (forfeited)\n", i+1, p.Score, p.ID))
+			continue
+		}
+
+		finalScores.WriteString(fmt.Sprintf("#%d: % 2dpts - <@%s>\n", i+1, p.Score, p.ID))
 	}
 
+	return finalScores.String()
+}
+
+func Scoreboard(ff *FireFight, scmd *SlackCmd) (SlackResponse, error) {
 	var topPlayers strings.Builder
+	if teams := ff.TeamScoreboard(); len(teams) > 0 {
+		topPlayers.WriteString(teamScoreboardText(teams))
+	}
 	topPlayers.WriteString("[FireFight Scoreboard]\n")
 
 	players := ff.Scoreboard()
 	for i, p := range players {
 		status := "active"
-		if p.Hit {
+		switch {
+		case p.Forfeited:
+			status = "forfeited"
+		case p.Hit:
 			status = "fragged"
 		}
 
 		topPlayers.WriteString(fmt.Sprintf("#%d: % 2dpts - <@%s> (%s)\n", i+1, p.Score, p.ID, status))
 	}
 
-	data := SlackResponse{Type: "ephemeral", Text: topPlayers.String()}
-
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(data); err != nil {
-		log.Println("[Scoreboard]", err)
-	}
+	return SlackResponse{Type: "ephemeral", Text: topPlayers.String()}, nil
 }
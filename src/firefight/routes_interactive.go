@@ -0,0 +1,116 @@
+package firefight
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Button action IDs. The button value names the hit, see hitValue.
+const (
+	ActionDispute = "ff_dispute"
+	ActionConfirm = "ff_confirm"
+)
+
+// hitValue is the button value for hit 'ref': the hit player, the attacker
+// and the hit time, space separated.
+func hitValue(ref HitRef) string {
+	return fmt.Sprintf("%s %s %d", ref.Target, ref.Attacker, ref.At.UnixNano())
+}
+
+// parseHitValue reverses hitValue. Buttons from before the attacker and hit
+// time were added only name the hit player, which can't match any hit.
+func parseHitValue(value string) HitRef {
+	fields := strings.Fields(value)
+	if len(fields) != 3 {
+		return HitRef{Target: value}
+	}
+
+	at, err := strconv.ParseInt(fields[2], 10, 64)
+	if err != nil {
+		return HitRef{Target: fields[0]}
+	}
+
+	return HitRef{Target: fields[0], Attacker: fields[1], At: time.Unix(0, at).UTC()}
+}
+
+// actionCommands names the command each button stands in for, see Message.
+var actionCommands = map[string]string{
+	ActionDispute: "dispute",
+	ActionConfirm: "confirm",
+}
+
+// Interactive handles button clicks on FFbot messages.
+//
+// Slack only wants a quick 200 here, the original message is updated
+// through the response_url.
+func Interactive(w http.ResponseWriter, r *http.Request) {
+	ff, ok := FireFightFrom(r.Context())
+	if !ok {
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+
+	si, ok := SlackInteractionFrom(r.Context())
+	if !ok {
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+
+	for _, action := range si.Actions {
+		data, err := hitAction(ff, si.User.ID, action)
+		if err != nil {
+			data = refuse(r.Context(), actionCommands[action.ActionID], err)
+		}
+
+		go func(data SlackResponse) {
+			if err := PostResponse(si.ResponseURL, data); err != nil {
+				Logger(r.Context()).Error("post response", "error", err)
+			}
+		}(data)
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// hitAction answers a Dispute or Confirm click by 'userID'.
+// Only the hit player may answer, and only while the hit is the one the
+// buttons were posted with. Errors are shown to the clicker alone.
+func hitAction(ff *FireFight, userID string, action SlackAction) (SlackResponse, error) {
+	ref := parseHitValue(action.Value)
+	if ref.Target != userID {
+		return SlackResponse{
+			Type: "ephemeral",
+			Text: fmt.Sprintf("Only <@%s> can answer for this hit.", ref.Target),
+		}, nil
+	}
+
+	switch action.ActionID {
+	case ActionDispute:
+		revived, err := ff.DisputeHitRef(ref)
+		if err != nil {
+			return SlackResponse{}, err
+		}
+
+		return SlackResponse{
+			Type:            "in_channel",
+			Text:            disputeText(userID, revived),
+			ReplaceOriginal: true,
+		}, nil
+
+	case ActionConfirm:
+		if err := ff.ConfirmHitRef(ref); err != nil {
+			return SlackResponse{}, err
+		}
+
+		return SlackResponse{
+			Type:            "in_channel",
+			Text:            fmt.Sprintf("<@%s> confirmed the hit. Rest in pieces.", userID),
+			ReplaceOriginal: true,
+		}, nil
+	}
+
+	return SlackResponse{Type: "ephemeral", Text: "Unknown action."}, nil
+}
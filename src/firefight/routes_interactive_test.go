@@ -0,0 +1,75 @@
+package firefight
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHitButtonsAnswerTheirHit(t *testing.T) {
+	ff, clock := newTestGame(t, "A", "B", "C")
+
+	cmd := func(user string) *SlackCmd {
+		return &SlackCmd{TeamID: "T1", ChannelID: "C1", UserID: user}
+	}
+
+	// buttons reports a hit by 'user' and returns its button values.
+	buttons := func(user string) map[string]string {
+		t.Helper()
+
+		resp, err := ReportHit(ff, cmd(user))
+		if err != nil {
+			t.Fatalf("ReportHit: %v", err)
+		}
+
+		values := make(map[string]string)
+		for _, b := range resp.Blocks {
+			for _, el := range b.Elements {
+				values[el.ActionID] = el.Value
+			}
+		}
+
+		return values
+	}
+
+	first := buttons("A")
+	if _, err := ff.DisputeHit("B"); err != nil {
+		t.Fatal(err)
+	}
+
+	clock.Advance(time.Minute)
+	second := buttons("A")
+
+	tests := []struct {
+		name   string
+		user   string
+		action string
+		value  string
+		want   error
+	}{
+		{"someone else", "C", ActionConfirm, second[ActionConfirm], nil},
+		{"settled hit", "B", ActionConfirm, first[ActionConfirm], ErrStaleHit},
+		{"settled hit dispute", "B", ActionDispute, first[ActionDispute], ErrStaleHit},
+		{"old button", "B", ActionConfirm, "B", ErrStaleHit},
+		{"other attacker", "B", ActionConfirm, hitValue(HitRef{Target: "B", Attacker: "C", At: clock.Now()}), ErrStaleHit},
+		{"current hit", "B", ActionConfirm, second[ActionConfirm], nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := hitAction(ff, tt.user, SlackAction{ActionID: tt.action, Value: tt.value})
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("got %v; want %v", err, tt.want)
+			}
+
+			if tt.user != "B" && !strings.Contains(resp.Text, "Only <@B>") {
+				t.Errorf("answered %q; want only B to answer", resp.Text)
+			}
+		})
+	}
+
+	if p := ff.Players[ff.find("B")]; !p.Hit || p.HitBy != "A" || !p.HitTimeout.Equal(clock.Now()) {
+		t.Errorf("B: %+v; want the second hit confirmed", p)
+	}
+}
@@ -1,155 +1,125 @@
 package firefight
 
 import (
-	"encoding/json"
 	"fmt"
-	"log"
-	"net/http"
+	"strings"
 )
 
-func Join(w http.ResponseWriter, r *http.Request) {
-	ff, ok := r.Context().Value("fire_fight").(*FireFight)
-	if !ok {
-		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
-		return
-	}
+func Join(ff *FireFight, scmd *SlackCmd) (SlackResponse, error) {
+	team := strings.TrimSpace(scmd.Text)
 
-	scmd, ok := r.Context().Value("slack_cmd").(*SlackCmd)
-	if !ok {
-		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
-		return
+	if err := ff.Join(scmd.UserID, team); err != nil {
+		return SlackResponse{}, err
 	}
 
-	var data SlackResponse
-	if err := ff.Join(scmd.UserID); err != nil {
-		data = SlackResponse{Type: "ephemeral", Text: err.Error()}
-	} else {
-		data = SlackResponse{
+	if team != "" {
+		return SlackResponse{
 			Type: "ephemeral",
-			Text: "You've joined the fight!",
-		}
+			Text: fmt.Sprintf("You've joined the fight on team %s!", strings.ToLower(team)),
+		}, nil
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(data); err != nil {
-		log.Println("[Join]", err)
-	}
+	return SlackResponse{
+		Type: "ephemeral",
+		Text: "You've joined the fight!",
+	}, nil
 }
 
-func Target(w http.ResponseWriter, r *http.Request) {
-	ff, ok := r.Context().Value("fire_fight").(*FireFight)
-	if !ok {
-		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
-		return
+func Leave(ff *FireFight, scmd *SlackCmd) (SlackResponse, error) {
+	p, err := ff.Leave(scmd.UserID)
+	if err != nil {
+		return SlackResponse{}, err
 	}
 
-	scmd, ok := r.Context().Value("slack_cmd").(*SlackCmd)
-	if !ok {
-		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
-		return
+	if !p.Forfeited {
+		return SlackResponse{Type: "ephemeral", Text: "You've left the lobby."}, nil
 	}
 
-	var data SlackResponse
-	if target, err := ff.GetTarget(scmd.UserID); err != nil {
-		data = SlackResponse{Type: "ephemeral", Text: err.Error()}
-	} else {
-		data = SlackResponse{
-			Type: "ephemeral",
-			Text: fmt.Sprintf("Your next target: <@%s>.", target.ID),
-		}
-	}
+	return SlackResponse{
+		Type: "in_channel",
+		Text: fmt.Sprintf("<@%s> left the fight. Forfeited with %d pts.", p.ID, p.Score),
+	}, nil
+}
 
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(data); err != nil {
-		log.Println("[Target]", err)
+func Target(ff *FireFight, scmd *SlackCmd) (SlackResponse, error) {
+	target, err := ff.GetTarget(scmd.UserID)
+	if err != nil {
+		return SlackResponse{}, err
 	}
+
+	return SlackResponse{
+		Type: "ephemeral",
+		Text: fmt.Sprintf("Your next target: <@%s>.", target.ID),
+	}, nil
 }
 
-func ReportHit(w http.ResponseWriter, r *http.Request) {
-	ff, ok := r.Context().Value("fire_fight").(*FireFight)
-	if !ok {
-		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
-		return
+func ReportHit(ff *FireFight, scmd *SlackCmd) (SlackResponse, error) {
+	target, err := ff.ReportHit(scmd.UserID)
+	if err != nil {
+		return SlackResponse{}, err
 	}
 
-	scmd, ok := r.Context().Value("slack_cmd").(*SlackCmd)
-	if !ok {
-		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
-		return
+	text := fmt.Sprintf("<@%s> has been hit!", target.ID)
+	if target.PendingBy != "" {
+		text = fmt.Sprintf("<@%s> claims a hit on <@%s>! <@%s>, confirm or dispute it.",
+			scmd.UserID, target.ID, target.ID)
 	}
 
-	var data SlackResponse
-	if target, err := ff.ReportHit(scmd.UserID); err != nil {
-		data = SlackResponse{Type: "ephemeral", Text: err.Error()}
-	} else {
-		data = SlackResponse{
-			Type: "in_channel",
-			Text: fmt.Sprintf("<@%s> has been hit!", target.ID),
-		}
-	}
+	value := hitValue(HitRef{Target: target.ID, Attacker: scmd.UserID, At: target.HitAt})
 
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(data); err != nil {
-		log.Println("[ReportHit]", err)
-	}
+	return SlackResponse{
+		Type: "in_channel",
+		Text: text,
+		Blocks: []Block{
+			SectionBlock(text),
+			ActionsBlock(
+				Button("Dispute", ActionDispute, value, "danger"),
+				Button("Confirm", ActionConfirm, value, "primary"),
+			),
+		},
+	}, nil
 }
 
-func DisputeHit(w http.ResponseWriter, r *http.Request) {
-	ff, ok := r.Context().Value("fire_fight").(*FireFight)
-	if !ok {
-		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
-		return
+func DisputeHit(ff *FireFight, scmd *SlackCmd) (SlackResponse, error) {
+	revived, err := ff.DisputeHit(scmd.UserID)
+	if err != nil {
+		return SlackResponse{}, err
 	}
 
-	scmd, ok := r.Context().Value("slack_cmd").(*SlackCmd)
-	if !ok {
-		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
-		return
-	}
+	return SlackResponse{
+		Type: "in_channel",
+		Text: disputeText(scmd.UserID, revived),
+	}, nil
+}
 
-	var data SlackResponse
-	if err := ff.DisputeHit(scmd.UserID); err != nil {
-		data = SlackResponse{Type: "ephemeral", Text: err.Error()}
-	} else {
-		data = SlackResponse{
-			Type: "in_channel",
-			Text: fmt.Sprintf("FFbot revived: <@%s>.", scmd.UserID),
-		}
+func disputeText(id string, revived bool) string {
+	if revived {
+		return fmt.Sprintf("FFbot revived: <@%s>.", id)
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(data); err != nil {
-		log.Println("[DisputeHit]", err)
-	}
+	return fmt.Sprintf("<@%s> disputes the hit. Waiting on a ruling.", id)
 }
 
-func DefendAttack(w http.ResponseWriter, r *http.Request) {
-	ff, ok := r.Context().Value("fire_fight").(*FireFight)
-	if !ok {
-		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
-		return
+func ConfirmHit(ff *FireFight, scmd *SlackCmd) (SlackResponse, error) {
+	if err := ff.ConfirmHit(scmd.UserID); err != nil {
+		return SlackResponse{}, err
 	}
 
-	scmd, ok := r.Context().Value("slack_cmd").(*SlackCmd)
-	if !ok {
-		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
-		return
-	}
+	return SlackResponse{
+		Type: "in_channel",
+		Text: fmt.Sprintf("<@%s> confirmed the hit. Rest in pieces.", scmd.UserID),
+	}, nil
+}
 
-	var data SlackResponse
+func DefendAttack(ff *FireFight, scmd *SlackCmd) (SlackResponse, error) {
 	if _, err := ff.Defend(scmd.UserID); err != nil {
-		data = SlackResponse{Type: "ephemeral", Text: err.Error()}
-	} else {
-		data = SlackResponse{
-			Type: "in_channel",
-
-			// Likely don't want to reveal if the defence was correct?
-			Text: fmt.Sprintf("<@%s> defended an attack.", scmd.UserID),
-		}
+		return SlackResponse{}, err
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(data); err != nil {
-		log.Println("[DefendAttack]", err)
-	}
+	return SlackResponse{
+		Type: "in_channel",
+
+		// Likely don't want to reveal if the defence was correct?
+		Text: fmt.Sprintf("<@%s> defended an attack.", scmd.UserID),
+	}, nil
 }
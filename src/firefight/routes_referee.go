@@ -0,0 +1,107 @@
+package firefight
+
+import (
+	"fmt"
+	"strings"
+)
+
+const refUsage = `Usage: /ffref <command>
+  add @user       Name a referee. Admins only.
+  remove @user    Drop a referee. Admins only.
+  list            Show the referees.
+  help            Show this message.
+  queue           Show hits waiting on a ruling.
+  uphold @user    Let the hit on @user stand.
+  overturn @user  Take back the hit on @user.`
+
+// Referee handles '/ffref'.
+func Referee(ff *FireFight, scmd *SlackCmd) (SlackResponse, error) {
+	fields := strings.Fields(scmd.Text)
+	if len(fields) == 0 {
+		return SlackResponse{Type: "ephemeral", Text: refUsage}, nil
+	}
+
+	switch action := strings.ToLower(fields[0]); action {
+	case "help":
+		return SlackResponse{Type: "ephemeral", Text: refUsage}, nil
+
+	case "list":
+		return SlackResponse{Type: "ephemeral", Text: refereesText(ff.RefereeIDs())}, nil
+
+	case "queue":
+		return SlackResponse{Type: "ephemeral", Text: disputesText(ff.Disputes())}, nil
+
+	case "add", "remove", "uphold", "overturn":
+		if len(fields) != 2 {
+			return SlackResponse{Type: "ephemeral", Text: refUsage}, nil
+		}
+
+		id, err := ParseUserID(fields[1])
+		if err != nil {
+			return SlackResponse{}, err
+		}
+
+		if action == "add" || action == "remove" {
+			if err := permit(ff, scmd); err != nil {
+				return SlackResponse{}, err
+			}
+		}
+
+		var text string
+		switch action {
+		case "add":
+			err = ff.AddReferee(scmd.UserID, id)
+			text = fmt.Sprintf("<@%s> is now a referee.", id)
+		case "remove":
+			err = ff.RemoveReferee(scmd.UserID, id)
+			text = fmt.Sprintf("<@%s> is no longer a referee.", id)
+		default:
+			text, err = ff.Rule(scmd.UserID, id, action == "uphold")
+		}
+
+		if err != nil {
+			return SlackResponse{}, err
+		}
+
+		return SlackResponse{Type: "in_channel", Text: text}, nil
+	}
+
+	return SlackResponse{
+		Type: "ephemeral",
+		Text: fmt.Sprintf("Unknown command %q.\n%s", fields[0], refUsage),
+	}, nil
+}
+
+func refereesText(refs []string) string {
+	if len(refs) == 0 {
+		return "No referees. Disputed hits are taken back right away."
+	}
+
+	mentions := make([]string, len(refs))
+	for i, id := range refs {
+		mentions[i] = fmt.Sprintf("<@%s>", id)
+	}
+
+	return "Referees: " + strings.Join(mentions, ", ")
+}
+
+func disputesText(queue []Dispute) string {
+	if len(queue) == 0 {
+		return "No hits waiting on a ruling."
+	}
+
+	var sb strings.Builder
+	sb.WriteString("Waiting on a ruling:")
+	for _, d := range queue {
+		switch {
+		case d.Claim:
+			fmt.Fprintf(&sb, "\n<@%s>'s claimed hit on <@%s>", d.Attacker, d.Player)
+		case d.Attacker != "":
+			fmt.Fprintf(&sb, "\n<@%s>'s hit on <@%s>, disputed", d.Attacker, d.Player)
+		default:
+			fmt.Fprintf(&sb, "\nHit on <@%s>, disputed", d.Player)
+		}
+	}
+
+	return sb.String()
+}
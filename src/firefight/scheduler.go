@@ -0,0 +1,218 @@
+package firefight
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// extendCooldowns pushes back every cooldown still running at 'from' by 'd'.
+// Used on unpause so a paused game doesn't eat into anyone's cooldown.
+func (pl PlayerList) extendCooldowns(from time.Time, d time.Duration) {
+	for i := range pl {
+		p := &pl[i]
+
+		if p.HitTimeout.After(from) {
+			p.HitTimeout = p.HitTimeout.Add(d)
+		}
+
+		if p.DefensiveTimeout.After(from) {
+			p.DefensiveTimeout = p.DefensiveTimeout.Add(d)
+		}
+
+		if p.PendingTimeout.After(from) {
+			p.PendingTimeout = p.PendingTimeout.Add(d)
+		}
+	}
+}
+
+// SetNotifier starts announcing expired cooldowns through 'n'.
+func (ff *FireFight) SetNotifier(n *Notifier) {
+	ff.mu.Lock()
+	defer ff.mu.Unlock()
+
+	ff.notifier = n
+	ff.schedule()
+}
+
+// SetStore saves the game to 's' as 'id' whenever the timer changes it,
+// e.g. respawns a player. Requests save their own changes.
+func (ff *FireFight) SetStore(s Store, id string) {
+	ff.mu.Lock()
+	defer ff.mu.Unlock()
+
+	ff.store = s
+	ff.storeID = id
+}
+
+// SetLogger makes the game log through 'l' when no request is at hand, e.g.
+// when the timer fires.
+func (ff *FireFight) SetLogger(l *slog.Logger) {
+	ff.mu.Lock()
+	defer ff.mu.Unlock()
+
+	ff.log = l
+}
+
+// Logger returns the game's logger, or the default one if none was set.
+func (ff *FireFight) Logger() *slog.Logger {
+	ff.mu.RLock()
+	defer ff.mu.RUnlock()
+
+	return ff.logger()
+}
+
+// ff.mu must be held.
+func (ff *FireFight) logger() *slog.Logger {
+	if ff.log != nil {
+		return ff.log
+	}
+
+	return slog.Default()
+}
+
+// Notifier returns where announcements go. May be nil.
+func (ff *FireFight) Notifier() *Notifier {
+	ff.mu.RLock()
+	defer ff.mu.RUnlock()
+
+	return ff.notifier
+}
+
+// schedule arms the game timer for the next cooldown to expire after the
+// last check, the next respawn or the end of a timed game, whichever is
+// first. Nothing runs while the game isn't active, unpausing reschedules.
+//
+// ff.mu must be held.
+func (ff *FireFight) schedule() {
+	if ff.timer != nil {
+		ff.timer.Stop()
+		ff.timer = nil
+	}
+
+	if ff.State != StateActive {
+		return
+	}
+
+	var next time.Time
+	consider := func(t time.Time) {
+		if next.IsZero() || t.Before(next) {
+			next = t
+		}
+	}
+
+	for _, p := range ff.Players {
+		for _, t := range [...]time.Time{p.HitTimeout, p.DefensiveTimeout, p.PendingTimeout} {
+			if t.After(ff.checked) {
+				consider(t)
+			}
+		}
+
+		// Overdue respawns still have to happen, e.g. after a restart.
+		if t, ok := ff.respawnAt(p); ok {
+			consider(t)
+		}
+	}
+
+	if !ff.EndsAt.IsZero() {
+		consider(ff.EndsAt)
+	}
+
+	if next.IsZero() {
+		return
+	}
+
+	ff.timer = ff.clock.AfterFunc(next.Sub(ff.clock.Now()), ff.expire)
+}
+
+// expire announces every cooldown that ran out since the last check,
+// settles unanswered claims, respawns players and ends timed games.
+// Whatever changed is saved, see SetStore.
+func (ff *FireFight) expire() {
+	ff.mu.Lock()
+
+	now := ff.clock.Now()
+	if ff.State != StateActive {
+		ff.mu.Unlock()
+		return
+	}
+
+	ff.changed = false
+	ff.acceptUnanswered(now)
+
+	notices := ff.Players.expiredBetween(ff.checked, now)
+	ff.checked = now
+
+	notices = append(notices, ff.respawn(now)...)
+
+	if text := ff.finishIfWon(now); text != "" {
+		notices = append(notices, text)
+	}
+
+	ff.schedule()
+
+	n, l := ff.notifier, ff.logger()
+	s, id, changed := ff.store, ff.storeID, ff.changed
+	ff.mu.Unlock()
+
+	if s != nil && changed {
+		if err := s.Save(id, ff); err != nil {
+			l.Error("save failed", "error", err)
+		}
+	}
+
+	if n == nil {
+		return
+	}
+
+	for _, text := range notices {
+		if err := n.Post(SlackResponse{Type: "in_channel", Text: text}); err != nil {
+			l.Error("post notice", "error", err)
+		}
+	}
+}
+
+// announce posts 'text' to the channel in the background.
+//
+// ff.mu must be held.
+func (ff *FireFight) announce(text string) {
+	n, l := ff.notifier, ff.logger()
+	if n == nil {
+		return
+	}
+
+	go func() {
+		if err := n.Post(SlackResponse{Type: "in_channel", Text: text}); err != nil {
+			l.Error("post announcement", "error", err)
+		}
+	}()
+}
+
+// expiredBetween describes cooldowns that ran out in (from, to].
+func (pl PlayerList) expiredBetween(from, to time.Time) []string {
+	expired := func(t time.Time) bool {
+		return t.After(from) && !t.After(to)
+	}
+
+	var notices []string
+	for _, p := range pl {
+		if p.Hit && !p.Disputed && expired(p.HitTimeout) {
+			if p.HitBy != "" {
+				notices = append(notices, fmt.Sprintf("<@%s>'s hit on <@%s> is confirmed.", p.HitBy, p.ID))
+			} else {
+				notices = append(notices, fmt.Sprintf("<@%s>'s hit is confirmed.", p.ID))
+			}
+		}
+
+		if p.PendingBy != "" && expired(p.PendingTimeout) {
+			notices = append(notices, fmt.Sprintf("<@%s>'s hit on <@%s> is waiting on a ruling.", p.PendingBy, p.ID))
+		}
+
+		// Naming the player would give away who is hunting whom.
+		if !p.Hit && expired(p.DefensiveTimeout) {
+			notices = append(notices, "A defensive lockout has ended. Watch your back.")
+		}
+	}
+
+	return notices
+}
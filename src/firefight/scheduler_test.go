@@ -0,0 +1,54 @@
+package firefight
+
+import (
+	"testing"
+	"time"
+)
+
+// saveCounter is a Store that only counts saves.
+type saveCounter struct {
+	saves int
+}
+
+func (s *saveCounter) Save(id string, ff *FireFight) error                   { s.saves++; return nil }
+func (s *saveCounter) Load(id string, opts ...Option) (*FireFight, error)    { return nil, nil }
+func (s *saveCounter) LoadAll(opts ...Option) (map[string]*FireFight, error) { return nil, nil }
+func (s *saveCounter) Archive(id string, ff *FireFight) error                { return nil }
+func (s *saveCounter) Delete(id string) error                                { return nil }
+
+func TestTimerSaves(t *testing.T) {
+	tests := []struct {
+		name    string
+		rules   string
+		players []string
+		after   time.Duration
+		want    int
+	}{
+		{"announcement only", "", []string{"A", "B", "C"}, HitCooldown, 0},
+		{"finish", "", []string{"A", "B"}, HitCooldown, 1},
+		{"unanswered claim", "confirm=on", []string{"A", "B", "C"}, DefaultConfirmWindow, 1},
+		{"respawn", "respawn=1m duration=1h", []string{"A", "B", "C"}, HitCooldown + time.Minute, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := ParseGameConfig(tt.rules)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ff, clock := newTestGameWith(t, cfg, tt.players...)
+			if _, err := ff.ReportHit("A"); err != nil {
+				t.Fatal(err)
+			}
+
+			s := &saveCounter{}
+			ff.SetStore(s, "T1_C1")
+
+			clock.Advance(tt.after)
+			if s.saves != tt.want {
+				t.Errorf("saved %d times; want %d", s.saves, tt.want)
+			}
+		})
+	}
+}
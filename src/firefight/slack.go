@@ -1,6 +1,16 @@
 package firefight
 
-import "net/url"
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
 
 type SlackCmd struct {
 	// This is a verification token, a deprecated feature that you shouldn't use
@@ -53,3 +63,173 @@ func ParseSlackCmd(v url.Values) *SlackCmd {
 		TriggerID:      v.Get("trigger_id"),
 	}
 }
+
+// ParseUserID returns the user ID of a mention like '<@U123|name>' as it
+// arrives in command text. Bare IDs are passed through.
+func ParseUserID(s string) (string, error) {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "<@") && strings.HasSuffix(s, ">") {
+		s = strings.TrimSuffix(strings.TrimPrefix(s, "<@"), ">")
+		if i := strings.IndexByte(s, '|'); i != -1 {
+			s = s[:i]
+		}
+	}
+
+	if s == "" || strings.ContainsAny(s, "<@>| ") {
+		return "", ErrNoMention
+	}
+
+	return s, nil
+}
+
+// Block Kit layout. Only the parts FFbot uses.
+// https://api.slack.com/reference/block-kit
+
+type Block struct {
+	Type     string         `json:"type"`
+	BlockID  string         `json:"block_id,omitempty"`
+	Text     *TextObject    `json:"text,omitempty"`
+	Elements []BlockElement `json:"elements,omitempty"`
+}
+
+type TextObject struct {
+	Type string `json:"type"` // "plain_text" or "mrkdwn"
+	Text string `json:"text"`
+}
+
+type BlockElement struct {
+	Type     string      `json:"type"`
+	Text     *TextObject `json:"text,omitempty"`
+	ActionID string      `json:"action_id,omitempty"`
+	Value    string      `json:"value,omitempty"`
+	Style    string      `json:"style,omitempty"` // "primary" or "danger"
+}
+
+func SectionBlock(text string) Block {
+	return Block{Type: "section", Text: &TextObject{Type: "mrkdwn", Text: text}}
+}
+
+func ActionsBlock(elements ...BlockElement) Block {
+	return Block{Type: "actions", Elements: elements}
+}
+
+func Button(text, actionID, value, style string) BlockElement {
+	return BlockElement{
+		Type:     "button",
+		Text:     &TextObject{Type: "plain_text", Text: text},
+		ActionID: actionID,
+		Value:    value,
+		Style:    style,
+	}
+}
+
+// SlackInteraction is the payload sent when a user clicks a button.
+// https://api.slack.com/reference/interaction-payloads/block-actions
+type SlackInteraction struct {
+	Type string `json:"type"`
+
+	Team struct {
+		ID     string `json:"id"`
+		Domain string `json:"domain"`
+	} `json:"team"`
+
+	// Null outside Enterprise Grid.
+	Enterprise *struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"enterprise"`
+
+	User struct {
+		ID       string `json:"id"`
+		Username string `json:"username"`
+	} `json:"user"`
+
+	Channel struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"channel"`
+
+	ResponseURL string `json:"response_url"`
+	TriggerID   string `json:"trigger_id"`
+
+	Actions []SlackAction `json:"actions"`
+}
+
+type SlackAction struct {
+	ActionID string `json:"action_id"`
+	BlockID  string `json:"block_id"`
+	Value    string `json:"value"`
+}
+
+// ParseSlackInteraction decodes the JSON 'payload' form value.
+func ParseSlackInteraction(v url.Values) (*SlackInteraction, error) {
+	var si SlackInteraction
+	if err := json.Unmarshal([]byte(v.Get("payload")), &si); err != nil {
+		return nil, err
+	}
+
+	return &si, nil
+}
+
+var slackClient = &http.Client{Timeout: 10 * time.Second}
+
+// PostResponse sends a message to a response_url.
+func PostResponse(responseURL string, data SlackResponse) error {
+	body, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	resp, err := slackClient.Post(responseURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("response_url: %s", resp.Status)
+	}
+
+	return nil
+}
+
+// Notifier posts to a game's channel outside of a command response.
+//
+// An incoming webhook is used when set. Otherwise the most recent
+// response_url seen in the channel is used, which Slack only honors for
+// 30 minutes.
+type Notifier struct {
+	WebhookURL string
+
+	mu          sync.Mutex
+	responseURL string
+}
+
+// Track remembers the latest response_url for the channel.
+func (n *Notifier) Track(responseURL string) {
+	if responseURL == "" {
+		return
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.responseURL = responseURL
+}
+
+func (n *Notifier) Post(data SlackResponse) error {
+	if n.WebhookURL != "" {
+		data.Type = "" // Webhooks always post in channel.
+		return PostResponse(n.WebhookURL, data)
+	}
+
+	n.mu.Lock()
+	responseURL := n.responseURL
+	n.mu.Unlock()
+
+	if responseURL == "" {
+		return errors.New("notifier: no webhook or response_url for channel")
+	}
+
+	return PostResponse(responseURL, data)
+}
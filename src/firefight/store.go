@@ -0,0 +1,325 @@
+package firefight
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Store persists games so they survive a restart.
+type Store interface {
+	// Save snapshots the current state of game 'id'.
+	Save(id string, ff *FireFight) error
+
+	// Load restores game 'id' with 'opts'. Returns nil without error if it
+	// was never saved.
+	Load(id string, opts ...Option) (*FireFight, error)
+
+	// LoadAll restores every saved game keyed by id.
+	LoadAll(opts ...Option) (map[string]*FireFight, error)
+
+	// Archive keeps a copy of game 'id' as it is now, e.g. once finished.
+	// Archived games are never loaded again.
+	Archive(id string, ff *FireFight) error
+
+	// Delete forgets game 'id'. Not an error if it was never saved.
+	Delete(id string) error
+}
+
+// playerSnapshot is the on-disk form of a Player.
+//
+// Unlike FireFight.MarshalJSON, timeouts are kept at full precision and always
+// written so a restored game behaves exactly like the one that was saved.
+type playerSnapshot struct {
+	ID    string
+	Team  string `json:",omitempty"`
+	Score int
+
+	DefensiveTimeout time.Time
+
+	HitTimeout time.Time
+	HitByID    string `json:",omitempty"`
+	HitAt      time.Time
+	Hit        bool
+	Disputed   bool `json:",omitempty"`
+
+	PendingBy      string `json:",omitempty"`
+	PendingTimeout time.Time
+}
+
+// gameSnapshot is the on-disk form of a FireFight.
+type gameSnapshot struct {
+	Created     time.Time
+	Updated     time.Time // Missing from older snapshots.
+	State       GameState
+	PausedAt    time.Time
+	Winner      string `json:",omitempty"`
+	WinningTeam string `json:",omitempty"`
+
+	Config *GameConfig // Missing from older snapshots.
+	EndsAt time.Time
+
+	Owner  string   `json:",omitempty"`
+	Admins []string `json:",omitempty"`
+	Banned []string `json:",omitempty"`
+
+	Players  []playerSnapshot // In ring order.
+	Referees []string         `json:",omitempty"`
+
+	Forfeited []playerSnapshot `json:",omitempty"` // ID, Team and Score only.
+}
+
+// snapshot copies the game state.
+func (ff *FireFight) snapshot() gameSnapshot {
+	ff.mu.RLock()
+	defer ff.mu.RUnlock()
+
+	snap := gameSnapshot{
+		Created:     ff.Created,
+		Updated:     ff.Updated,
+		State:       ff.State,
+		PausedAt:    ff.PausedAt,
+		Winner:      ff.Winner,
+		WinningTeam: ff.WinningTeam,
+		Config:      &ff.Config,
+		EndsAt:      ff.EndsAt,
+		Players:     make([]playerSnapshot, len(ff.Players)),
+		Owner:       ff.Owner,
+		Admins:      ff.Admins,
+		Banned:      ff.Banned,
+		Referees:    ff.Referees,
+	}
+
+	for _, p := range ff.Forfeited {
+		snap.Forfeited = append(snap.Forfeited, playerSnapshot{ID: p.ID, Team: p.Team, Score: p.Score})
+	}
+
+	for i, p := range ff.Players {
+		snap.Players[i] = playerSnapshot{
+			ID:               p.ID,
+			Team:             p.Team,
+			Score:            p.Score,
+			DefensiveTimeout: p.DefensiveTimeout,
+			HitTimeout:       p.HitTimeout,
+			HitByID:          p.HitBy,
+			HitAt:            p.HitAt,
+			Hit:              p.Hit,
+			Disputed:         p.Disputed,
+			PendingBy:        p.PendingBy,
+			PendingTimeout:   p.PendingTimeout,
+		}
+	}
+
+	return snap
+}
+
+// restore rebuilds a game from a snapshot with 'opts', keeping the ring
+// order intact.
+func restore(snap gameSnapshot, opts ...Option) *FireFight {
+	ff := &FireFight{
+		Created:     snap.Created,
+		Updated:     snap.Updated,
+		State:       snap.State,
+		PausedAt:    snap.PausedAt,
+		Winner:      snap.Winner,
+		WinningTeam: snap.WinningTeam,
+		Config:      DefaultGameConfig(),
+		defaults:    DefaultGameConfig(),
+		EndsAt:      snap.EndsAt,
+		Players:     make(PlayerList, len(snap.Players)),
+		Owner:       snap.Owner,
+		Admins:      snap.Admins,
+		Banned:      snap.Banned,
+		Referees:    snap.Referees,
+		clock:       RealClock{},
+	}
+	for _, opt := range opts {
+		opt(ff)
+	}
+
+	// Don't announce cooldowns that ran out while we were down.
+	ff.checked = ff.clock.Now()
+
+	if snap.Config != nil {
+		ff.Config = *snap.Config
+	}
+
+	if ff.Updated.IsZero() {
+		ff.Updated = ff.Created
+	}
+
+	for _, ps := range snap.Forfeited {
+		ff.Forfeited = append(ff.Forfeited, Player{ID: ps.ID, Team: ps.Team, Score: ps.Score, Forfeited: true})
+	}
+
+	for i, ps := range snap.Players {
+		ff.Players[i] = Player{
+			ID:               ps.ID,
+			Team:             ps.Team,
+			Score:            ps.Score,
+			DefensiveTimeout: ps.DefensiveTimeout,
+			HitTimeout:       ps.HitTimeout,
+			HitBy:            ps.HitByID,
+			HitAt:            ps.HitAt,
+			Hit:              ps.Hit,
+			Disputed:         ps.Disputed,
+			PendingBy:        ps.PendingBy,
+			PendingTimeout:   ps.PendingTimeout,
+		}
+	}
+
+	ff.reindex()
+
+	return ff
+}
+
+// FileStore keeps one JSON snapshot per game in a directory.
+type FileStore struct {
+	Dir string
+
+	mu    sync.Mutex
+	locks map[string]*sync.Mutex // Per game, see lock.
+}
+
+// NewFileStore creates 'dir' if needed.
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	return &FileStore{Dir: dir}, nil
+}
+
+const (
+	snapshotExt = ".json"
+	archiveDir  = "archive"
+)
+
+func (fs *FileStore) path(id string) string {
+	return filepath.Join(fs.Dir, filepath.Base(id)+snapshotExt)
+}
+
+// lock returns the lock saves of game 'id' take turns on, so a snapshot
+// taken first is never renamed over one taken later.
+func (fs *FileStore) lock(id string) *sync.Mutex {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	if fs.locks == nil {
+		fs.locks = make(map[string]*sync.Mutex)
+	}
+
+	l, ok := fs.locks[id]
+	if !ok {
+		l = new(sync.Mutex)
+		fs.locks[id] = l
+	}
+
+	return l
+}
+
+// Save writes to a temp file and renames it over the old snapshot so a
+// crash mid-write never leaves a truncated game behind.
+func (fs *FileStore) Save(id string, ff *FireFight) error {
+	l := fs.lock(id)
+	l.Lock()
+	defer l.Unlock()
+
+	return fs.write(fs.path(id), ff.snapshot())
+}
+
+// Archive saves game 'id' under Dir/archive, named after the time of its
+// last change. LoadAll skips the archive.
+func (fs *FileStore) Archive(id string, ff *FireFight) error {
+	dir := filepath.Join(fs.Dir, archiveDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	snap := ff.snapshot()
+	name := fmt.Sprintf("%s-%d%s", filepath.Base(id), snap.Updated.Unix(), snapshotExt)
+
+	return fs.write(filepath.Join(dir, name), snap)
+}
+
+func (fs *FileStore) Delete(id string) error {
+	l := fs.lock(id)
+	l.Lock()
+	defer l.Unlock()
+
+	if err := os.Remove(fs.path(id)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return nil
+}
+
+func (fs *FileStore) write(path string, snap gameSnapshot) error {
+	data, err := json.Marshal(snap)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+func (fs *FileStore) Load(id string, opts ...Option) (*FireFight, error) {
+	data, err := ioutil.ReadFile(fs.path(id))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var snap gameSnapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return nil, err
+	}
+
+	return restore(snap, opts...), nil
+}
+
+func (fs *FileStore) LoadAll(opts ...Option) (map[string]*FireFight, error) {
+	files, err := ioutil.ReadDir(fs.Dir)
+	if err != nil {
+		return nil, err
+	}
+
+	games := make(map[string]*FireFight, len(files))
+	for _, fi := range files {
+		name := fi.Name()
+		if fi.IsDir() || !strings.HasSuffix(name, snapshotExt) {
+			continue
+		}
+
+		id := strings.TrimSuffix(name, snapshotExt)
+		ff, err := fs.Load(id, opts...)
+		if err != nil {
+			return nil, err
+		}
+
+		games[id] = ff
+	}
+
+	return games, nil
+}
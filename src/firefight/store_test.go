@@ -0,0 +1,63 @@
+package firefight
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFileStoreRoundTrip(t *testing.T) {
+	cfg := DefaultGameConfig()
+	cfg.ConfirmWindow = DefaultConfirmWindow
+
+	ff, clock := newTestGameWith(t, cfg, "A", "B", "C", "D", "E")
+	if _, err := ff.Defend("C"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ff.ReportHit("D"); err != nil {
+		t.Fatal(err)
+	}
+	if err := ff.ConfirmHit("E"); err != nil {
+		t.Fatal(err)
+	}
+	clock.Advance(time.Minute)
+	if _, err := ff.ReportHit("A"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Join mid-game lands at a random spot in the ring.
+	if err := ff.Join("F", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	fs, err := NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := fs.Save("T1_C1", ff); err != nil {
+		t.Fatal(err)
+	}
+
+	restored, err := fs.Load("T1_C1", WithClock(clock))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(restored.Players) != len(ff.Players) {
+		t.Fatalf("restored %d players; want %d", len(restored.Players), len(ff.Players))
+	}
+
+	for i, want := range ff.Players {
+		got := restored.Players[i]
+		if got.ID != want.ID || got.HitBy != want.HitBy || got.Hit != want.Hit || got.PendingBy != want.PendingBy ||
+			!got.HitTimeout.Equal(want.HitTimeout) || !got.DefensiveTimeout.Equal(want.DefensiveTimeout) ||
+			!got.PendingTimeout.Equal(want.PendingTimeout) || !got.HitAt.Equal(want.HitAt) {
+			t.Errorf("player %d: got %+v; want %+v", i, got, want)
+		}
+	}
+
+	// Cooldowns run on the game's clock, not the wall clock.
+	if got, want := restored.Stats(), ff.Stats(); got != want {
+		t.Errorf("restored stats %+v; want %+v", got, want)
+	}
+}
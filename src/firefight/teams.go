@@ -0,0 +1,116 @@
+package firefight
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Team games
+//
+// Players join a named team and only hunt players on other teams. Targets
+// follow the same ring rule, teammates are simply skipped over. The game is
+// won by the last team with players standing.
+
+// normalizeTeam makes team names case insensitive.
+func normalizeTeam(team string) (string, error) {
+	team = strings.ToLower(strings.TrimSpace(team))
+	if strings.ContainsAny(team, "<>@ ") {
+		return "", ErrBadTeamName
+	}
+
+	return team, nil
+}
+
+// teamMode reports if this is a team game. Join keeps every player either
+// on a team or not.
+func (pl PlayerList) teamMode() bool {
+	return len(pl) > 0 && pl[0].Team != ""
+}
+
+// checkTeams makes sure a team game is playable before it starts.
+func (pl PlayerList) checkTeams() error {
+	if !pl.teamMode() {
+		return nil
+	}
+
+	teams := make(map[string]bool)
+	for _, p := range pl {
+		teams[p.Team] = true
+	}
+
+	if len(teams) < 2 {
+		return ErrTooFewTeams
+	}
+
+	return nil
+}
+
+// teammates reports if both players are on the same team.
+func teammates(a, b Player) bool {
+	return a.Team != "" && a.Team == b.Team
+}
+
+type TeamScore struct {
+	Team  string
+	Score int
+	Alive int // Players still standing.
+}
+
+// teamScores totals points per team, best first. Team 'first' always leads,
+// e.g. the winning team.
+func teamScores(players []Player, first string) []TeamScore {
+	var teams []TeamScore
+	index := make(map[string]int)
+
+	for _, p := range players {
+		if p.Team == "" {
+			continue
+		}
+
+		i, ok := index[p.Team]
+		if !ok {
+			i = len(teams)
+			index[p.Team] = i
+			teams = append(teams, TeamScore{Team: p.Team})
+		}
+
+		teams[i].Score += p.Score
+		if !p.Hit && !p.Forfeited {
+			teams[i].Alive++
+		}
+	}
+
+	sort.SliceStable(teams, func(i, j int) bool {
+		if teams[i].Team == first {
+			return teams[j].Team != first
+		}
+		if teams[j].Team == first {
+			return false
+		}
+
+		return teams[j].Score < teams[i].Score
+	})
+
+	return teams
+}
+
+// TeamScoreboard returns the team totals, winning team first.
+// Empty for free-for-all games.
+func (ff *FireFight) TeamScoreboard() []TeamScore {
+	ff.mu.RLock()
+	defer ff.mu.RUnlock()
+
+	return teamScores(ff.standings(), ff.WinningTeam)
+}
+
+// teamScoreboardText formats team totals.
+func teamScoreboardText(teams []TeamScore) string {
+	var b strings.Builder
+	b.WriteString("[FireFight Teams]\n")
+	for i, t := range teams {
+		b.WriteString(fmt.Sprintf("#%d: % 2dpts - %s (%d standing)\n", i+1, t.Score, t.Team, t.Alive))
+	}
+
+	return b.String()
+}
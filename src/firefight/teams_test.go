@@ -0,0 +1,40 @@
+package firefight
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEndCountsWholeTeams(t *testing.T) {
+	ff := New(WithClock(NewFakeClock(epoch)))
+	for _, p := range []struct{ id, team string }{{"A", "red"}, {"B", "red"}, {"C", "blue"}, {"D", "blue"}} {
+		if err := ff.Join(p.id, p.team); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cfg := DefaultGameConfig()
+	cfg.Shuffle = false
+	if err := ff.StartWithConfig("A", cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ff.ReportHit("A"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ff.Pause(); err != nil {
+		t.Fatal(err)
+	}
+
+	_, teams, err := ff.End()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Nobody on blue scored, nor did B on red.
+	want := []TeamScore{{Team: "red", Score: 1, Alive: 2}, {Team: "blue", Score: 0, Alive: 1}}
+	if !reflect.DeepEqual(teams, want) {
+		t.Errorf("got %+v; want %+v", teams, want)
+	}
+}
@@ -0,0 +1,139 @@
+package firefight
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"strings"
+	"sync"
+)
+
+// GameKey identifies a game.
+//
+// Channel IDs are only unique within a workspace, and shared channels are
+// seen from several workspaces, so every workspace gets its own game.
+type GameKey struct {
+	EnterpriseID string // Empty outside Enterprise Grid.
+	TeamID       string
+	ChannelID    string
+}
+
+// String is the key as used for file names, e.g. 'E01_T01_C01' or 'T01_C01'.
+func (k GameKey) String() string {
+	parts := []string{k.TeamID, k.ChannelID}
+	if k.EnterpriseID != "" {
+		parts = append([]string{k.EnterpriseID}, parts...)
+	}
+
+	return strings.Join(parts, "_")
+}
+
+// ParseGameKey reverses GameKey.String. A bare channel ID, as games were
+// keyed before workspaces, leaves the team empty.
+func ParseGameKey(s string) GameKey {
+	parts := strings.Split(s, "_")
+	switch len(parts) {
+	case 1:
+		return GameKey{ChannelID: parts[0]}
+	case 2:
+		return GameKey{TeamID: parts[0], ChannelID: parts[1]}
+	}
+
+	return GameKey{EnterpriseID: parts[0], TeamID: parts[1], ChannelID: strings.Join(parts[2:], "_")}
+}
+
+// Key returns the game the command was sent to.
+func (scmd *SlackCmd) Key() GameKey {
+	return GameKey{EnterpriseID: scmd.EnterpriseID, TeamID: scmd.TeamID, ChannelID: scmd.ChannelID}
+}
+
+// Key returns the game the button was clicked in.
+func (si *SlackInteraction) Key() GameKey {
+	k := GameKey{TeamID: si.Team.ID, ChannelID: si.Channel.ID}
+	if si.Enterprise != nil {
+		k.EnterpriseID = si.Enterprise.ID
+	}
+
+	return k
+}
+
+// WorkspaceConfig holds the settings of one Slack workspace.
+type WorkspaceConfig struct {
+	// BotToken lets workspace admins override game permissions.
+	// Needs the users:read scope.
+	BotToken string `json:",omitempty"`
+
+	// Webhooks maps channel IDs to incoming webhook URLs.
+	Webhooks map[string]string `json:",omitempty"`
+
+	// Rules new games are played by unless /ffstart says otherwise,
+	// e.g. "hit-cooldown=10m shuffle=off".
+	Rules string `json:",omitempty"`
+
+	once sync.Once
+	api  *SlackAPI
+}
+
+// API returns a Slack Web API client for the workspace. Nil without a token.
+func (wc *WorkspaceConfig) API() *SlackAPI {
+	wc.once.Do(func() {
+		if wc.BotToken != "" {
+			wc.api = &SlackAPI{Token: wc.BotToken}
+		}
+	})
+
+	return wc.api
+}
+
+// DefaultTeam is the WorkspaceConfigs entry used for unlisted workspaces.
+const DefaultTeam = "*"
+
+// WorkspaceConfigs maps team IDs to their settings.
+type WorkspaceConfigs map[string]*WorkspaceConfig
+
+// LoadWorkspaceConfigs reads a JSON object of team ID to WorkspaceConfig.
+// The rules of every workspace are checked up front.
+func LoadWorkspaceConfigs(path string) (WorkspaceConfigs, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	wcs := make(WorkspaceConfigs)
+	if err := json.Unmarshal(data, &wcs); err != nil {
+		return nil, err
+	}
+
+	for _, wc := range wcs {
+		if _, err := wc.GameConfig(); err != nil {
+			return nil, err
+		}
+	}
+
+	return wcs, nil
+}
+
+// For returns the settings of workspace 'teamID', falling back to the
+// DefaultTeam entry. Never nil.
+func (wcs WorkspaceConfigs) For(teamID string) *WorkspaceConfig {
+	if wc, ok := wcs[teamID]; ok {
+		return wc
+	}
+
+	if wc, ok := wcs[DefaultTeam]; ok {
+		return wc
+	}
+
+	return &WorkspaceConfig{}
+}
+
+// GameConfig parses the workspace's default rules.
+func (wc *WorkspaceConfig) GameConfig() (GameConfig, error) {
+	if strings.TrimSpace(wc.Rules) == "" {
+		return DefaultGameConfig(), nil
+	}
+
+	return ParseGameConfig(wc.Rules)
+}
+
+// Workspaces holds the settings of every workspace the app is installed in.
+var Workspaces = make(WorkspaceConfigs)